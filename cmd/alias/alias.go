@@ -0,0 +1,51 @@
+package alias
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// AliasCmd represents the alias management command
+var AliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "🔗 Manage command aliases and macros",
+	Long: `🔗 Aliases and Macros
+
+Aliases are shortcuts for longer command lines, macros run several commands
+one after the other. Both are defined in the global or project config:
+
+  aliases:
+    errs: logs tail {{1}} --since 30m --follow
+  macros:
+    morning:
+      - cache refresh
+      - logs tail {{1}} --since 12h
+
+'pcli errs my-service' then runs 'pcli logs tail my-service --since 30m
+--follow'. {{1}}, {{2}}, ... are replaced by the arguments of the alias,
+{{@}} by all of them, and arguments no placeholder uses are appended.
+Global flags given before the alias name apply to every command it runs.
+
+Built-in and plugin commands always win: an alias or macro named like one
+is ignored and reported by 'pcli config validate'.
+
+Available Commands:
+  list     📋 List all aliases and macros
+
+Examples:
+  pcli config set aliases.errs "logs tail {{1}} --since 30m --follow"
+  pcli config set macros.morning "cache refresh,logs tail {{1}} --since 12h"
+  pcli alias list
+
+Use 'pcli alias <command> --help' for more information about specific commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Display available subcommands and usage
+		fmt.Println("🔗 Alias Commands")
+		fmt.Println()
+		fmt.Println("Available commands:")
+		fmt.Println("  list     📋 List all aliases and macros")
+		fmt.Println()
+		fmt.Println("Use 'pcli alias <command> --help' for more information.")
+	},
+}
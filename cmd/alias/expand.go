@@ -0,0 +1,226 @@
+package alias
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// maxDepth limits aliases expanding to aliases and macros running macros,
+// so a definition that refers to itself fails instead of looping forever
+const maxDepth = 10
+
+// depthEnv passes the macro nesting depth on to the pcli processes a macro
+// runs
+const depthEnv = "PCLI_MACRO_DEPTH"
+
+// Invocation is a command line naming an alias or macro
+type Invocation struct {
+	Alias internal.Alias
+	// Globals are the global flags given before the alias name
+	Globals []string
+	// Args are the arguments after the alias name
+	Args []string
+}
+
+// ExpandArgs replaces an alias at the start of args (os.Args[1:]) by the
+// command it stands for, following aliases of aliases. A macro is returned
+// instead, to be run by RunMacro. For shell completion requests the alias
+// is expanded up to its first placeholder, so its arguments complete like
+// those of the command it runs.
+func ExpandArgs(root *cobra.Command, args []string) ([]string, *Invocation, error) {
+	// fish and bash without descriptions ask with __completeNoDesc
+	var request string
+	if len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd) {
+		request, args = args[0], args[1:]
+	}
+	completing := request != ""
+
+	for depth := 0; ; depth++ {
+		inv, ok := resolve(root, args)
+		if !ok {
+			break
+		}
+		if depth == maxDepth {
+			return nil, nil, fmt.Errorf("alias '%s' expands into itself", inv.Alias.Name)
+		}
+		if inv.Alias.Macro {
+			if completing {
+				return nil, nil, nil
+			}
+			return nil, &inv, nil
+		}
+
+		var expanded []string
+		if completing {
+			expanded = append(internal.AliasPrefix(inv.Alias.Steps[0]), inv.Args...)
+		} else {
+			var err error
+			if expanded, err = internal.ExpandAlias(inv.Alias.Steps[0], inv.Args); err != nil {
+				return nil, nil, fmt.Errorf("alias '%s' %v", inv.Alias.Name, err)
+			}
+		}
+		args = append(append([]string{}, inv.Globals...), expanded...)
+	}
+
+	if completing {
+		args = append([]string{request}, args...)
+	}
+	return args, nil, nil
+}
+
+// resolve finds the alias or macro named by the first word of args that is
+// not a global flag. Built-in commands always win over aliases.
+func resolve(root *cobra.Command, args []string) (Invocation, bool) {
+	i, configFile := CommandWord(root, args)
+	if i < 0 {
+		return Invocation{}, false
+	}
+	name := strings.ToLower(args[i])
+	if isCommand(root, name) {
+		return Invocation{}, false
+	}
+	a, ok := internal.LoadAliases(globalConfigPath(configFile))[name]
+	if !ok {
+		return Invocation{}, false
+	}
+	return Invocation{Alias: a, Globals: args[:i], Args: args[i+1:]}, true
+}
+
+// CommandWord returns the index of the command name in args, the first word
+// that is not one of root's global flags or their values, or -1 when there
+// is none or an unknown flag comes first. It also returns the --config value
+// given before it, if any.
+func CommandWord(root *cobra.Command, args []string) (int, string) {
+	configFile := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1, configFile
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i, configFile
+		}
+
+		// Skip the flag and its value, unless the value is attached with "="
+		name, value, attached := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag := root.PersistentFlags().Lookup(name)
+		if flag == nil && !strings.HasPrefix(arg, "--") && len(name) == 1 {
+			flag = root.PersistentFlags().ShorthandLookup(name)
+		}
+		if flag == nil {
+			// An unknown flag; leave the error to cobra
+			return -1, configFile
+		}
+		if !attached && flag.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if flag.Name == "config" {
+			configFile = value
+		}
+	}
+	return -1, configFile
+}
+
+// isCommand reports whether name is a built-in command or one of its aliases
+func isCommand(root *cobra.Command, name string) bool {
+	if name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	for _, builtin := range BuiltinNames(root) {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// globalConfigPath returns the config file pcli will load: the --config
+// value, else the default file in the home directory
+func globalConfigPath(configFile string) string {
+	if configFile != "" {
+		return configFile
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return internal.FindConfigFile(home, ".pcli")
+}
+
+// RunMacro runs the steps of a macro one after the other, each as its own
+// pcli process with the global flags of the invocation, and stops at the
+// first step that fails. It returns the exit code for pcli.
+func RunMacro(inv Invocation) int {
+	depth, _ := strconv.Atoi(os.Getenv(depthEnv))
+	if depth >= maxDepth {
+		fmt.Fprintf(os.Stderr, "❌ Error: macro '%s' runs itself\n", inv.Alias.Name)
+		return 1
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		return 1
+	}
+
+	for i, step := range inv.Alias.Steps {
+		args, err := internal.ExpandAlias(step, inv.Args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: macro '%s' step %d %v\n", inv.Alias.Name, i+1, err)
+			return 1
+		}
+		args = append(append([]string{}, inv.Globals...), args...)
+		fmt.Fprintf(os.Stderr, "▶️  [%d/%d] pcli %s\n", i+1, len(inv.Alias.Steps), strings.Join(args, " "))
+
+		cmd := exec.Command(exe, args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", depthEnv, depth+1))
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Macro '%s' stopped at step %d: %v\n", inv.Alias.Name, i+1, err)
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return exitErr.ExitCode()
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Complete offers the names of aliases and macros next to the built-in
+// commands
+func Complete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, a := range internal.SortedAliases(internal.LoadAliases(viper.ConfigFileUsed())) {
+		if isCommand(cmd.Root(), a.Name) || !strings.HasPrefix(a.Name, toComplete) {
+			continue
+		}
+		description := "alias for pcli " + a.Steps[0]
+		if a.Macro {
+			description = fmt.Sprintf("macro of %d commands", len(a.Steps))
+		}
+		names = append(names, a.Name+"\t"+description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// BuiltinNames returns the names and aliases of the commands of root,
+// including the help and completion commands cobra adds
+func BuiltinNames(root *cobra.Command) []string {
+	names := []string{"help", "completion"}
+	for _, cmd := range root.Commands() {
+		names = append(names, cmd.Name())
+		names = append(names, cmd.Aliases...)
+	}
+	return names
+}
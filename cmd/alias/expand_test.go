@@ -0,0 +1,38 @@
+package alias
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestExpandArgsCompletion checks that completion requests, with or without
+// descriptions, expand an alias only up to its first placeholder and are
+// passed on with the request they came with.
+func TestExpandArgsCompletion(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "pcli.yaml")
+	if err := os.WriteFile(config, []byte("aliases:\n  errs: logs tail {{1}} --since 30m\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	root := &cobra.Command{Use: "pcli"}
+	root.PersistentFlags().String("config", "", "")
+	root.AddCommand(&cobra.Command{Use: "logs"})
+
+	for _, request := range []string{cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd} {
+		args, macro, err := ExpandArgs(root, []string{request, "--config", config, "errs", ""})
+		if err != nil || macro != nil {
+			t.Fatalf("%s: %v, %v", request, macro, err)
+		}
+		want := request + " --config " + config + " logs tail "
+		if got := strings.Join(args, " "); got != want {
+			t.Errorf("%s: args = %q, want %q", request, got, want)
+		}
+	}
+
+	if _, _, err := ExpandArgs(root, []string{"--config", config, "errs"}); err == nil {
+		t.Error("expected an error for the missing argument")
+	}
+}
@@ -0,0 +1,55 @@
+package alias
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// listCmd represents the command listing all aliases and macros
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "📋 List all aliases and macros",
+	Long: `📋 List Aliases
+
+List the aliases and macros of the global and project config with the
+commands they run. Project definitions replace global ones with the same
+name; definitions named like a built-in command are marked as ignored.
+
+Examples:
+  pcli alias list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		aliases := internal.SortedAliases(internal.LoadAliases(viper.ConfigFileUsed()))
+		if len(aliases) == 0 {
+			fmt.Println("🔗 No aliases or macros configured")
+			fmt.Println("Use 'pcli config set aliases.<name> \"<command>\"' to create one")
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"Name", "Type", "Runs", "Scope", "Status"})
+		for _, a := range aliases {
+			kind := "alias"
+			if a.Macro {
+				kind = "macro"
+			}
+			status := "✅ active"
+			if kind := internal.ShadowingCommand(a.Name); kind != "" {
+				status = fmt.Sprintf("⚠️  ignored, shadows a %s command", kind)
+			}
+			table.Append([]string{a.Name, kind, "pcli " + strings.Join(a.Steps, "\npcli "), a.Scope, status})
+		}
+		table.Render()
+		fmt.Printf("\n📊 Total aliases: %d\n", len(aliases))
+	},
+}
+
+func init() {
+	AliasCmd.AddCommand(listCmd)
+}
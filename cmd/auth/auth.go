@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// AuthCmd represents the authentication command
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "🔐 Sign in with AWS IAM Identity Center (SSO)",
+	Long: `🔐 Authentication
+
+Sign in to AWS IAM Identity Center (SSO) using the device authorization flow.
+The SSO access token and the role credentials fetched with it are cached
+under $XDG_CACHE_HOME/pcli/sso and refreshed automatically before every AWS
+call, so log tailing and completion keep working without 'aws sso login'.
+
+The start URL and SSO region come from the current context (sso_start_url,
+sso_region) or the sso.start_url/sso.region config defaults. Contexts with
+sso_account_id and sso_role_name use the SSO session for their credentials.
+
+Available Commands:
+  login    🔑 Sign in through the browser
+  logout   🚪 Sign out and remove cached tokens
+  status   📋 Show the SSO session status
+
+Examples:
+  pcli auth login
+  pcli auth login --start-url https://my-org.awsapps.com/start --sso-region eu-west-1
+  pcli auth status
+  pcli auth logout
+
+Use 'pcli auth <command> --help' for more information about specific commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Display available subcommands and usage
+		fmt.Println("🔐 Authentication Commands")
+		fmt.Println()
+		fmt.Println("Available commands:")
+		fmt.Println("  login    🔑 Sign in through the browser")
+		fmt.Println("  logout   🚪 Sign out and remove cached tokens")
+		fmt.Println("  status   📋 Show the SSO session status")
+		fmt.Println()
+		fmt.Println("Use 'pcli auth <command> --help' for more information.")
+	},
+}
+
+var (
+	startURL  string
+	ssoRegion string
+)
+
+// ssoConfig resolves the SSO settings of the current context, applying the
+// --start-url/--sso-region flags to the returned config and target alike.
+func ssoConfig() (internal.Target, internal.SSOConfig, error) {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		return internal.Target{}, internal.SSOConfig{}, err
+	}
+
+	cfg := internal.SSOConfigFor(target)
+	if startURL != "" {
+		cfg.StartURL = startURL
+	}
+	if ssoRegion != "" {
+		cfg.Region = ssoRegion
+	}
+	if cfg.StartURL == "" {
+		return internal.Target{}, internal.SSOConfig{}, fmt.Errorf("no SSO start URL configured (use --start-url or set sso.start_url)")
+	}
+	// Credentials of the target come from the instance signed in to
+	target.SSOStartURL, target.SSORegion = cfg.StartURL, cfg.Region
+	return target, cfg, nil
+}
+
+func init() {
+	AuthCmd.PersistentFlags().StringVar(&startURL, "start-url", "",
+		"🌐 SSO start URL (default: from context or sso.start_url)")
+	AuthCmd.PersistentFlags().StringVar(&ssoRegion, "sso-region", "",
+		"🌍 Region of the IAM Identity Center instance (default: from context or sso.region)")
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// loginCmd represents the SSO login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "🔑 Sign in through the browser",
+	Long: `🔑 SSO Login
+
+Start the SSO device authorization flow. Open the printed URL, confirm the
+code and approve the request; pcli waits until the login completes and
+caches the session.
+
+Examples:
+  pcli auth login
+  pcli auth login --context prod`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, cfg, err := ssoConfig()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("🔑 Signing in to %s...\n", cfg.StartURL)
+		token, err := internal.SSOLogin(cfg, func(auth internal.DeviceAuthorization) {
+			fmt.Println()
+			fmt.Println("Open the following URL in your browser and approve the request:")
+			fmt.Printf("  %s\n", auth.VerificationURIComplete)
+			fmt.Println()
+			fmt.Printf("Verification code: %s\n", auth.UserCode)
+			fmt.Println()
+			fmt.Println("⏳ Waiting for approval...")
+		})
+		if err != nil {
+			fmt.Printf("❌ Error signing in: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Signed in (session valid until %s)\n", token.ExpiresAt.Local().Format("2006-01-02 15:04"))
+
+		// Verify the context's role is reachable with the new session
+		if target.UsesSSO() {
+			if _, err := internal.SSOCredentials(target); err != nil {
+				fmt.Printf("⚠️  Warning: Could not get credentials for role %s in account %s: %v\n",
+					target.SSORoleName, target.SSOAccountID, err)
+				return
+			}
+			fmt.Printf("✅ Credentials for role %s in account %s are ready\n", target.SSORoleName, target.SSOAccountID)
+		}
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(loginCmd)
+}
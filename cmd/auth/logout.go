@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// logoutCmd represents the SSO logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "🚪 Sign out and remove cached tokens",
+	Long: `🚪 SSO Logout
+
+Sign out of the SSO session and remove the cached access token and role
+credentials of the start URL.
+
+Examples:
+  pcli auth logout`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, cfg, err := ssoConfig()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		if err := internal.SSOLogout(cfg); err != nil {
+			fmt.Printf("❌ Error signing out: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Signed out of %s\n", cfg.StartURL)
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(logoutCmd)
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the SSO status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "📋 Show the SSO session status",
+	Long: `📋 SSO Status
+
+Show whether an SSO session exists for the configured start URL and when it
+expires.
+
+Examples:
+  pcli auth status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, cfg, err := ssoConfig()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		token, err := internal.SSOStatus(cfg)
+		if err != nil {
+			fmt.Printf("🔒 Not signed in to %s\n", cfg.StartURL)
+			fmt.Println("Use 'pcli auth login' to sign in")
+			return
+		}
+
+		fmt.Printf("📋 SSO session for %s\n", cfg.StartURL)
+		fmt.Printf("  Region:     %s\n", token.Region)
+		switch {
+		case token.Valid():
+			fmt.Printf("  Status:     ✅ active (expires in %s)\n", time.Until(token.ExpiresAt).Round(time.Minute))
+		case token.RefreshToken != "":
+			fmt.Println("  Status:     🔄 expired, will be refreshed on next use")
+		default:
+			fmt.Println("  Status:     ❌ expired, use 'pcli auth login' to sign in again")
+		}
+
+		if target.UsesSSO() {
+			fmt.Printf("  Account:    %s\n", target.SSOAccountID)
+			fmt.Printf("  Role:       %s\n", target.SSORoleName)
+		}
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(statusCmd)
+}
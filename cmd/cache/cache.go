@@ -4,17 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 
 	"github.com/rashi1281/pcli/internal"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var cacheRefreshFunc = []func() error{
-	internal.CacheLogGroups,
-}
+var (
+	outputFormat string
+	listSort     string
+)
 
 // CacheCmd represents the cache management command
 var CacheCmd = &cobra.Command{
@@ -24,24 +26,81 @@ var CacheCmd = &cobra.Command{
 
 Manage cached data for the CLI tool. The cache stores frequently accessed 
 information like AWS log groups, service configurations, and other data 
-to improve performance and reduce API calls.
+to improve performance and reduce API calls. It lives in its own file,
+$XDG_CACHE_HOME/pcli/cache.json (default ~/.cache/pcli/cache.json), separate
+from your settings.
 
 Available Commands:
   clear    🗑️  Clear all cached data
+  delete   ❌ Delete specific cached entries
+  prune    🧹 Remove stale or old cached entries
   list     📋 List all cached entries with details
-  get      🔍 Get specific cached entry by key
+  get      🔍 Get a cached entry by key or path query
   refresh  🔄 Refresh cache by fetching latest data
+  providers 🧩 List the providers that fill the cache
+  export   📤 Write the cache to stdout as a seed file
+  import   📥 Load a seed file into the cache
+  warm     🔥 Keep the cache fresh in the background
 
 Examples:
   pcli cache list                    # Show all cached entries
+  pcli cache list --sort age         # Oldest entries first
+  pcli cache list -o json            # Entry metadata for scripts
   pcli cache clear                   # Clear all cache
-  pcli cache get log-groups          # Get cached log groups
+  pcli cache clear --context staging # Clear one environment's cache only
+  pcli cache delete log_groups       # Drop one entry of the current target
+  pcli cache delete log_groups --all-scopes  # ...of every profile/region
+  pcli cache prune                   # Remove stale entries (TTL or --max-age)
+  pcli cache prune --older-than 7d   # Remove entries older than a week
+  pcli cache get log_groups          # Get cached log groups
+  pcli cache get 'log_groups[0]'     # Get the first cached log group
+  pcli cache get 'log_groups[?name~prod].name' -o plain  # Names containing "prod", one per line
   pcli cache refresh                 # Refresh all cache data
+  pcli cache refresh log_groups      # Refresh selected providers only
+  pcli cache refresh --concurrency 8 --timeout 30s
+  pcli cache export > seed.json      # Share your cache with the team
+  pcli cache import seed.json --merge  # Add newer entries from a seed file
+  pcli cache warm --daemon           # Refresh entries as they expire
+  pcli cache warm status             # Show the warmer and its recent log
+  pcli cache warm stop               # Stop the background warmer
+  pcli cache list --profile prod     # Show cached entries of another profile
+  pcli cache list --max-age 1h       # Treat entries older than an hour as stale
+
+'get' accepts JSONPath-style queries: .field, [n], [a:b], [*], [?text] and
+[?field=value]; choose the output with -o json|yaml|plain.
+
+Every entry records the schema version of its data; entries cached by an older
+pcli are upgraded when read, or dropped and fetched again when they cannot be.
+
+Every entry records when it was fetched and stays fresh for its TTL
+(cache_ttl.<key> in the config, otherwise the provider's default); --max-age
+overrides the TTL.
+Completion serves stale entries instantly and refreshes them in the background.
+
+'clear' and 'prune' work on every profile/region unless --context, --profile
+or --region selects one; 'delete' works on the current one unless
+--all-scopes is given.
+
+Imports replace the cache of the current target unless --merge is given, in
+which case only missing or newer entries are taken. Set cache_seed in the
+config to a seed file to merge it into the cache automatically: every version
+of the file is imported once per profile/region, recorded in
+seed_imports.json next to the cache, so a cleared cache stays empty.
+
+'warm' refreshes providers whenever their entries go stale, in the foreground,
+as a background daemon (--daemon) or as a systemd user unit
+(--install-systemd). It warms the contexts listed in cache_warm.contexts, or
+the current target, and logs every refresh to warm.log next to the cache.
 
+Providers are refreshed in parallel (--concurrency) with a time limit per
+provider (--timeout), followed by a summary of duration, items and errors.
+
+Cached data is kept separately for every AWS profile/region combination,
+selected with the global --context, --profile and --region flags.
 The cache is automatically managed and will be refreshed when needed.
 Use 'pcli cache --help' for more information about specific commands.`,
-	Args:      cobra.RangeArgs(1, 2),
-	ValidArgs: []string{"clear", "list", "get", "refresh"},
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeCacheArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		command := args[0]
 
@@ -51,119 +110,280 @@ Use 'pcli cache --help' for more information about specific commands.`,
 		case "list":
 			handleCacheList()
 		case "get":
-			if len(args) < 2 {
-				fmt.Println("❌ Error: Key is required for 'get' command")
-				fmt.Println("Usage: pcli cache get <key>")
+			if len(args) != 2 {
+				fmt.Println("❌ Error: Exactly one key or query is required for 'get' command")
+				fmt.Println("Usage: pcli cache get <key>[path]")
 				return
 			}
 			handleCacheGet(args[1])
 		case "refresh":
-			handleCacheRefresh()
+			handleCacheRefresh(args[1:])
+		case "providers":
+			handleCacheProviders()
+		case "delete":
+			if len(args) < 2 {
+				fmt.Println("❌ Error: At least one key is required for 'delete' command")
+				fmt.Println("Usage: pcli cache delete <key...> [--all-scopes]")
+				return
+			}
+			handleCacheDelete(args[1:])
+		case "prune":
+			handleCachePrune()
+		case "export":
+			handleCacheExport()
+		case "import":
+			if len(args) != 2 {
+				fmt.Println("❌ Error: A seed file is required for 'import' command")
+				fmt.Println("Usage: pcli cache import <file|-> [--merge]")
+				return
+			}
+			handleCacheImport(args[1])
+		case "warm":
+			handleCacheWarm(args[1:])
 		default:
 			fmt.Printf("❌ Error: Unknown command '%s'\n", command)
-			fmt.Println("Available commands: clear, list, get, refresh")
+			fmt.Println("Available commands: clear, delete, prune, list, get, refresh, providers, export, import, warm")
 			cmd.Help()
 		}
 	},
 }
 
-// handleCacheClear clears all cached data
-func handleCacheClear() {
-	fmt.Println("🗑️  Clearing cache...")
-	err := internal.DeleteConfigKey("cache")
+// handleCacheList displays all cached entries of the current profile/region
+// in a formatted table, or as JSON with -o json
+func handleCacheList() {
+	target, err := internal.CurrentTarget()
 	if err != nil {
-		fmt.Printf("❌ Error clearing cache: %v\n", err)
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	infos, err := internal.ListCache(target)
+	if err != nil {
+		fmt.Printf("❌ Error reading cache: %v\n", err)
+		return
+	}
+	if err := internal.SortCacheInfo(infos, listSort); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
 		return
 	}
-	fmt.Println("✅ Cache cleared successfully")
-}
 
-// handleCacheList displays all cached entries in a formatted table
-func handleCacheList() {
-	cache := viper.GetStringMap("cache")
+	switch outputFormat {
+	case "", "table":
+	case "json":
+		if infos == nil {
+			infos = []internal.CacheInfo{}
+		}
+		out, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Error formatting cache list: %v\n", err)
+			return
+		}
+		fmt.Println(string(out))
+		return
+	default:
+		fmt.Printf("❌ Error: Unsupported output format '%s' for 'list' (supported: table, json)\n", outputFormat)
+		return
+	}
 
-	if len(cache) == 0 {
-		fmt.Println("📋 Cache is empty")
+	if len(infos) == 0 {
+		fmt.Printf("📋 Cache is empty for %s\n", target)
 		return
 	}
 
-	fmt.Println("📋 Cached entries:")
+	fmt.Printf("📋 Cached entries for %s:\n", target)
 	fmt.Println()
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header([]string{"#", "Key", "Type", "Size"})
-
-	i := 1
-	for key, val := range cache {
-		valType := fmt.Sprintf("%T", val)
-		size := "N/A"
-
-		// Try to get size information
-		if valStr, ok := val.(string); ok {
-			size = fmt.Sprintf("%d chars", len(valStr))
-		} else if valMap, ok := val.(map[string]interface{}); ok {
-			size = fmt.Sprintf("%d items", len(valMap))
-		} else if valSlice, ok := val.([]interface{}); ok {
-			size = fmt.Sprintf("%d items", len(valSlice))
+	table.Header([]string{"#", "Key", "Provider", "Items", "Size", "Fetched", "Age", "Status", "Context"})
+
+	totalBytes := 0
+	for i, info := range infos {
+		provider := info.Provider
+		if provider == "" {
+			provider = "—"
+		}
+		items := "—"
+		if info.Items >= 0 {
+			items = fmt.Sprintf("%d", info.Items)
+		}
+		fetched, age := "unknown", "unknown"
+		if !info.FetchedAt.IsZero() {
+			fetched = info.FetchedAt.Local().Format(time.DateTime)
+			age = info.Age
+		}
+		contextName := info.Context
+		if contextName == "" {
+			contextName = "—"
 		}
 
 		table.Append([]string{
-			fmt.Sprintf("%d", i),
-			key,
-			valType,
-			size,
+			fmt.Sprintf("%d", i+1),
+			info.Key,
+			provider,
+			items,
+			formatBytes(info.Bytes),
+			fetched,
+			age,
+			formatStatus(info),
+			contextName,
 		})
-		i++
+		totalBytes += info.Bytes
 	}
 
 	table.Render()
-	fmt.Printf("\n📊 Total entries: %d\n", len(cache))
+	fmt.Printf("\n📊 Total entries: %d (%s)\n", len(infos), formatBytes(totalBytes))
 }
 
-// handleCacheGet retrieves and displays a specific cached entry
-func handleCacheGet(key string) {
-	cacheKey := fmt.Sprintf("cache.%s", key)
-	cache := viper.Get(cacheKey)
+// formatStatus renders whether an entry is fresh and for how long, or stale
+func formatStatus(info internal.CacheInfo) string {
+	if info.Stale {
+		return "⚠️  stale (ttl " + info.TTL + ")"
+	}
+	return "✅ fresh for " + time.Until(info.ExpiresAt).Round(time.Second).String()
+}
+
+// formatBytes renders a size in bytes with a binary unit
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatAge renders how long ago an entry was fetched
+func formatAge(entry internal.CacheEntry) string {
+	if entry.FetchedAt.IsZero() {
+		return "unknown"
+	}
+	return entry.Age().Round(time.Second).String()
+}
+
+// handleCacheGet retrieves and displays a specific cached entry, or the part
+// of it selected by a path query like log_groups[0]
+func handleCacheGet(query string) {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	key, path := internal.SplitQuery(query)
+	entry, ok := internal.GetCacheEntry(target, key)
 
-	if cache == nil {
+	if !ok {
 		fmt.Printf("❌ Cache entry '%s' not found\n", key)
+		if suggestions := internal.Suggest(key, cacheKeys(target)); len(suggestions) > 0 {
+			fmt.Printf("Did you mean '%s'?\n", suggestions[0])
+		}
 		fmt.Println("Use 'pcli cache list' to see available entries")
 		return
 	}
 
-	fmt.Printf("🔍 Cache entry '%s':\n", key)
-	fmt.Println()
+	value, err := internal.QueryValue(entry.Value, path)
+	if err != nil {
+		fmt.Printf("❌ Error: Invalid query '%s': %v\n", query, err)
+		return
+	}
 
-	jsonString, err := json.MarshalIndent(cache, "", "  ")
+	format := outputFormat
+	if format == "" {
+		format = "json"
+	}
+	formatted, err := internal.FormatValue(value, format)
 	if err != nil {
 		fmt.Printf("❌ Error formatting cache data: %v\n", err)
 		return
 	}
 
-	fmt.Println(string(jsonString))
-}
-
-// handleCacheRefresh refreshes all cached data
-func handleCacheRefresh() {
-	fmt.Println("🔄 Refreshing cache...")
+	// Only the data goes to stdout, so every format can be piped, e.g. to jq
+	fmt.Fprintf(os.Stderr, "🔍 Cache entry '%s' (fetched %s ago)\n", query, formatAge(entry))
+	if entry.Stale() {
+		fmt.Fprintln(os.Stderr, "⚠️  This entry is stale; use 'pcli cache refresh' to update it")
+	}
 
-	successCount := 0
-	totalCount := len(cacheRefreshFunc)
+	fmt.Println(formatted)
+}
 
-	for i, refreshFunc := range cacheRefreshFunc {
-		if err := refreshFunc(); err != nil {
-			fmt.Printf("⚠️  Warning: Error refreshing cache item %d: %v\n", i+1, err)
-		} else {
-			successCount++
+// cacheKeys returns the keys that can be looked up in the namespace of
+// target: everything cached there plus all provider names
+func cacheKeys(target internal.Target) []string {
+	seen := map[string]bool{}
+	var keys []string
+	entries, _ := internal.CacheEntries(target)
+	for key := range entries {
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	for _, name := range internal.CacheProviderNames() {
+		if !seen[name] {
+			keys = append(keys, name)
 		}
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	if successCount == totalCount {
-		fmt.Println("✅ Cache refreshed successfully")
-	} else {
-		fmt.Printf("⚠️  Cache refresh completed with %d/%d successful\n", successCount, totalCount)
+// completeCacheArgs completes cache subcommands and, for refresh, the names
+// of the cache providers
+func completeCacheArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return []string{"clear", "delete", "prune", "list", "get", "refresh", "providers", "export", "import", "warm"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	switch args[0] {
+	case "refresh":
+		return internal.CacheProviderNames(), cobra.ShellCompDirectiveNoFileComp
+	case "warm":
+		if len(args) == 1 {
+			return []string{"status", "stop"}, cobra.ShellCompDirectiveNoFileComp
+		}
+	case "import":
+		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	case "get", "delete":
+		if args[0] == "get" && len(args) > 1 {
+			break
+		}
+		target, err := internal.CurrentTarget()
+		if err != nil {
+			break
+		}
+		entries, _ := internal.CacheEntries(target)
+		var keys []string
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys, cobra.ShellCompDirectiveNoFileComp
 	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
 }
 
 func init() {
+	CacheCmd.Flags().StringVarP(&outputFormat, "output", "o", "",
+		"📄 Output format: json (default), yaml or plain for 'get'; table (default) or json for 'list'")
+	CacheCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(append([]string{"table"}, internal.OutputFormats...), cobra.ShellCompDirectiveNoFileComp))
+	CacheCmd.Flags().StringVar(&listSort, "sort", "key",
+		"🔃 For 'list': sort by key, provider, age, expires, items or size")
+	CacheCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(internal.CacheSortFields, cobra.ShellCompDirectiveNoFileComp))
+
+	CacheCmd.Flags().BoolVar(&allScopes, "all-scopes", false,
+		"🌐 For 'delete': delete the keys for every profile/region")
+	CacheCmd.Flags().StringVar(&olderThan, "older-than", "",
+		"🧹 For 'prune': remove entries older than this (e.g. 12h, 7d, 2w)")
+	CacheCmd.Flags().BoolVar(&importMerge, "merge", false,
+		"🔀 For 'import': keep cached entries newer than the seed's")
+	CacheCmd.Flags().IntVar(&refreshConcurrency, "concurrency", 0,
+		"⚡ Providers to refresh in parallel (default: cache_refresh.concurrency or 4)")
+	CacheCmd.Flags().DurationVar(&refreshTimeout, "timeout", 0,
+		"⏱️  Time limit per provider refresh (default: cache_refresh.timeout or 2m)")
+	CacheCmd.Flags().BoolVar(&warmDaemon, "daemon", false,
+		"🔥 For 'warm': run the cache warmer in the background")
+	CacheCmd.Flags().BoolVar(&warmInstall, "install-systemd", false,
+		"⚙️  For 'warm': install the cache warmer as a systemd user unit")
+	CacheCmd.Flags().DurationVar(&warmInterval, "interval", internal.DefaultWarmInterval,
+		"⏲️  For 'warm': check for stale entries at least this often")
 }
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rashi1281/pcli/internal"
+)
+
+var (
+	allScopes bool
+	olderThan string
+)
+
+// selectedScope returns the cache namespace picked explicitly with
+// --context, --profile or --region, or "" when none of them was given
+func selectedScope() (string, error) {
+	if internal.Overrides.Context == "" && internal.Overrides.Profile == "" && internal.Overrides.Region == "" {
+		return "", nil
+	}
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		return "", err
+	}
+	return target.Scope(), nil
+}
+
+// handleCacheClear clears all cached data, or only that of the explicitly
+// selected profile/region
+func handleCacheClear() {
+	scope, err := selectedScope()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	if scope == "" {
+		fmt.Println("🗑️  Clearing cache...")
+		if err := internal.ClearCache(); err != nil {
+			fmt.Printf("❌ Error clearing cache: %v\n", err)
+			return
+		}
+		fmt.Println("✅ Cache cleared successfully")
+		return
+	}
+
+	fmt.Printf("🗑️  Clearing cache for %s...\n", scope)
+	removed, err := internal.ClearCacheScope(scope)
+	if err != nil {
+		fmt.Printf("❌ Error clearing cache: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Cache cleared successfully (%d entries removed)\n", len(removed))
+}
+
+// handleCacheDelete deletes the given keys from the current profile/region,
+// or from all of them with --all-scopes
+func handleCacheDelete(keys []string) {
+	scope := ""
+	if !allScopes {
+		target, err := internal.CurrentTarget()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		scope = target.Scope()
+	}
+
+	removed, err := internal.DeleteCacheEntries(scope, keys)
+	if err != nil {
+		fmt.Printf("❌ Error deleting cache entries: %v\n", err)
+		return
+	}
+
+	if len(removed) == 0 {
+		fmt.Println("📋 No matching cache entries found")
+		fmt.Println("Use 'pcli cache list' to see available entries")
+		return
+	}
+	printRemoved(removed)
+	fmt.Printf("✅ Deleted %d cache entries\n", len(removed))
+}
+
+// handleCachePrune removes stale entries, past their TTL or --max-age, or
+// entries older than --older-than
+func handleCachePrune() {
+	var age time.Duration
+	if olderThan != "" {
+		var err error
+		if age, err = internal.ParseDuration(olderThan); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+	}
+
+	scope, err := selectedScope()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	removed, err := internal.PruneCache(scope, age)
+	if err != nil {
+		fmt.Printf("❌ Error pruning cache: %v\n", err)
+		return
+	}
+
+	if len(removed) == 0 {
+		fmt.Println("🧹 Nothing to prune")
+		return
+	}
+	printRemoved(removed)
+	fmt.Printf("✅ Pruned %d cache entries\n", len(removed))
+}
+
+// printRemoved lists removed entries with their namespace and age
+func printRemoved(removed []internal.RemovedEntry) {
+	for _, r := range removed {
+		fmt.Printf("  🗑️  %s/%s (age %s)\n", r.Scope, r.Key, r.Age.Round(time.Second))
+	}
+}
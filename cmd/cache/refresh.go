@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+)
+
+var (
+	refreshConcurrency int
+	refreshTimeout     time.Duration
+)
+
+// spinnerFrames animate running providers in the live progress display
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// handleCacheRefresh refreshes the named cache providers, or all of them,
+// together with the providers they depend on
+func handleCacheRefresh(names []string) {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	providers, err := internal.ResolveCacheProviders(names)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		fmt.Println("Use 'pcli cache providers' to see available providers")
+		return
+	}
+
+	// Prompt for MFA (if needed) before the progress display takes over
+	if err := target.EnsureCredentials(); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	opts := internal.RefreshOptionsFromConfig()
+	if refreshConcurrency > 0 {
+		opts.Concurrency = refreshConcurrency
+	}
+	if refreshTimeout > 0 {
+		opts.Timeout = refreshTimeout
+	}
+
+	fmt.Printf("🔄 Refreshing cache for %s...\n", target)
+
+	progress := newRefreshProgress(providers)
+	results := internal.RefreshCacheProviders(context.Background(), target, providers, opts, progress.update)
+	progress.stop()
+
+	fmt.Println()
+	renderRefreshSummary(results)
+
+	successCount := 0
+	for _, r := range results {
+		if r.State == internal.RefreshDone {
+			successCount++
+		}
+	}
+	fmt.Println()
+	if successCount == len(results) {
+		fmt.Println("✅ Cache refreshed successfully")
+	} else {
+		fmt.Printf("⚠️  Cache refresh completed with %d/%d successful\n", successCount, len(results))
+	}
+}
+
+// refreshProgress renders the state of every provider while a refresh runs.
+// On a terminal the lines are redrawn in place; otherwise each provider is
+// reported once when it finishes.
+type refreshProgress struct {
+	mu          sync.Mutex
+	results     []internal.RefreshResult
+	index       map[string]int
+	interactive bool
+	drawn       bool
+	frame       int
+	quit        chan struct{}
+	finished    chan struct{}
+}
+
+func newRefreshProgress(providers []internal.CacheProvider) *refreshProgress {
+	p := &refreshProgress{
+		results:  make([]internal.RefreshResult, len(providers)),
+		index:    map[string]int{},
+		quit:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	for i, provider := range providers {
+		p.results[i] = internal.RefreshResult{Provider: provider.Name, Items: -1}
+		p.index[provider.Name] = i
+	}
+	if stat, err := os.Stdout.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		p.interactive = true
+	}
+
+	go p.loop()
+	return p
+}
+
+// update records a state change reported by the refresh
+func (p *refreshProgress) update(r internal.RefreshResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[p.index[r.Provider]] = r
+
+	if !p.interactive {
+		switch r.State {
+		case internal.RefreshDone, internal.RefreshFailed, internal.RefreshSkipped:
+			fmt.Println(progressLine(r, ""))
+		}
+	}
+}
+
+// loop redraws the progress lines until stop is called
+func (p *refreshProgress) loop() {
+	defer close(p.finished)
+	if !p.interactive {
+		<-p.quit
+		return
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		p.draw()
+		select {
+		case <-ticker.C:
+		case <-p.quit:
+			p.draw()
+			return
+		}
+	}
+}
+
+// draw rewrites all progress lines in place
+func (p *refreshProgress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn {
+		// Move the cursor back to the first progress line
+		fmt.Printf("\033[%dA", len(p.results))
+	}
+	spinner := spinnerFrames[p.frame%len(spinnerFrames)]
+	p.frame++
+	for _, r := range p.results {
+		fmt.Printf("\033[2K%s\n", progressLine(r, spinner))
+	}
+	p.drawn = true
+}
+
+// stop ends the live display after drawing the final state
+func (p *refreshProgress) stop() {
+	close(p.quit)
+	<-p.finished
+}
+
+// progressLine renders the state of a single provider
+func progressLine(r internal.RefreshResult, spinner string) string {
+	switch r.State {
+	case internal.RefreshRunning:
+		return fmt.Sprintf("  %s %s (%s)", spinner, r.Provider, time.Since(r.Started).Round(100*time.Millisecond))
+	case internal.RefreshDone:
+		return fmt.Sprintf("  ✅ %s (%s)", r.Provider, r.Duration.Round(time.Millisecond))
+	case internal.RefreshFailed:
+		return fmt.Sprintf("  ❌ %s: %v", r.Provider, r.Err)
+	case internal.RefreshSkipped:
+		return fmt.Sprintf("  ⏭️  %s: %v", r.Provider, r.Err)
+	default:
+		return fmt.Sprintf("  ⏳ %s", r.Provider)
+	}
+}
+
+// renderRefreshSummary prints duration, item count and error per provider
+func renderRefreshSummary(results []internal.RefreshResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header([]string{"Provider", "Status", "Duration", "Items", "Error"})
+
+	for _, r := range results {
+		status, duration, items, errMsg := "✅ ok", "-", "N/A", ""
+		switch r.State {
+		case internal.RefreshFailed:
+			status = "❌ failed"
+		case internal.RefreshSkipped:
+			status = "⏭️  skipped"
+		}
+		if r.State == internal.RefreshDone || r.State == internal.RefreshFailed {
+			duration = r.Duration.Round(time.Millisecond).String()
+		}
+		if r.Items >= 0 {
+			items = fmt.Sprintf("%d", r.Items)
+		}
+		if r.Err != nil {
+			errMsg = strings.TrimSpace(r.Err.Error())
+		}
+		table.Append([]string{r.Provider, status, duration, items, errMsg})
+	}
+	table.Render()
+}
+
+// handleCacheProviders lists all registered cache providers
+func handleCacheProviders() {
+	providers := internal.CacheProviders()
+	if len(providers) == 0 {
+		fmt.Println("🧩 No cache providers registered")
+		return
+	}
+
+	fmt.Println("🧩 Cache providers:")
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header([]string{"Name", "Description", "TTL", "Depends On"})
+	for _, p := range providers {
+		table.Append([]string{
+			p.Name,
+			p.Description,
+			internal.CacheTTL(p.Name).String(),
+			strings.Join(p.DependsOn, ", "),
+		})
+	}
+	table.Render()
+}
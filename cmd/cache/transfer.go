@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+)
+
+var importMerge bool
+
+// handleCacheExport writes the cache of the current target to stdout as a
+// versioned seed envelope
+func handleCacheExport() {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		return
+	}
+
+	seed, err := internal.ExportCache(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error exporting cache: %v\n", err)
+		return
+	}
+	if len(seed.Entries) == 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Cache is empty for %s; use 'pcli cache refresh' first\n", target)
+	}
+
+	out, err := json.MarshalIndent(seed, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error exporting cache: %v\n", err)
+		return
+	}
+	// Only the envelope goes to stdout so it can be redirected into a file
+	fmt.Println(string(out))
+}
+
+// handleCacheImport validates a seed file and loads it into the cache of the
+// current target
+func handleCacheImport(path string) {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	seed, err := internal.ReadSeed(path)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	if seed.Source.Region != "" && target.Region != "" && seed.Source.Region != target.Region {
+		fmt.Printf("⚠️  Warning: Seed was exported from region %s, importing into %s\n", seed.Source.Region, target.Region)
+	}
+
+	result, err := internal.ImportCache(target, seed, importMerge)
+	if err != nil {
+		fmt.Printf("❌ Error importing cache: %v\n", err)
+		return
+	}
+
+	sort.Strings(result.Imported)
+	sort.Strings(result.Skipped)
+	fmt.Printf("✅ Imported %d entries into cache for %s", len(result.Imported), target)
+	if len(result.Imported) > 0 {
+		fmt.Printf(": %s", strings.Join(result.Imported, ", "))
+	}
+	fmt.Println()
+	if len(result.Skipped) > 0 {
+		fmt.Printf("⏭️  Kept %d cached entries that are at least as new: %s\n", len(result.Skipped), strings.Join(result.Skipped, ", "))
+	}
+	if len(result.Unknown) > 0 {
+		sort.Strings(result.Unknown)
+		fmt.Printf("⚠️  Warning: No provider refreshes these keys: %s\n", strings.Join(result.Unknown, ", "))
+	}
+	if len(result.Incompatible) > 0 {
+		sort.Strings(result.Incompatible)
+		fmt.Printf("⚠️  Warning: Skipped entries with an unsupported schema: %s\n", strings.Join(result.Incompatible, ", "))
+	}
+}
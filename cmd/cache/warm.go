@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/viper"
+)
+
+var (
+	warmDaemon   bool
+	warmInstall  bool
+	warmInterval time.Duration
+)
+
+// handleCacheWarm runs the cache warmer, starts it in the background, installs
+// it as a systemd user unit or, with an action, reports on or stops it
+func handleCacheWarm(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "status":
+			handleCacheWarmStatus()
+		case "stop":
+			handleCacheWarmStop()
+		default:
+			fmt.Printf("❌ Error: Unknown warm action '%s'\n", args[0])
+			fmt.Println("Usage: pcli cache warm [status|stop] [--daemon|--install-systemd] [--interval 5m]")
+		}
+		return
+	}
+
+	switch {
+	case warmInstall:
+		path, err := internal.InstallWarmUnit(warmInterval)
+		if err != nil {
+			fmt.Printf("❌ Error installing systemd unit: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Installed %s\n", path)
+		fmt.Println("Enable it with:")
+		fmt.Println("  systemctl --user daemon-reload")
+		fmt.Printf("  systemctl --user enable --now %s\n", internal.WarmUnitName)
+
+	case warmDaemon:
+		pid, err := internal.StartWarmDaemon(warmInterval)
+		if errors.Is(err, internal.ErrWarmRunning) {
+			fmt.Printf("ℹ️  Cache warmer is already running (pid %d)\n", pid)
+			return
+		}
+		if err != nil {
+			fmt.Printf("❌ Error starting cache warmer: %v\n", err)
+			return
+		}
+		logPath, _ := internal.WarmLogPath()
+		fmt.Printf("🔥 Cache warmer started in the background (pid %d)\n", pid)
+		fmt.Printf("📝 Logging refresh outcomes to %s\n", logPath)
+
+	default:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var out io.Writer = os.Stdout
+		if viper.GetBool("quiet") {
+			out = nil
+		} else {
+			fmt.Println("🔥 Warming the cache; press Ctrl+C to stop")
+		}
+		if err := internal.WarmCache(ctx, warmInterval, out); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// handleCacheWarmStatus reports whether the cache warmer runs and shows its
+// latest log lines
+func handleCacheWarmStatus() {
+	status, err := internal.GetWarmStatus()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	if status.Running {
+		fmt.Printf("🔥 Cache warmer is running (pid %d", status.PID)
+		if !status.Since.IsZero() {
+			fmt.Printf(", since %s", status.Since.Format(time.DateTime))
+		}
+		fmt.Println(")")
+	} else {
+		fmt.Println("💤 Cache warmer is not running")
+		fmt.Println("Start it with 'pcli cache warm --daemon'")
+	}
+
+	logPath, err := internal.WarmLogPath()
+	if err != nil {
+		return
+	}
+	lines, err := internal.TailWarmLog(10)
+	if err != nil || len(lines) == 0 {
+		return
+	}
+	fmt.Printf("\n📝 Recent activity (%s):\n", logPath)
+	for _, line := range lines {
+		fmt.Println("  " + line)
+	}
+}
+
+// handleCacheWarmStop stops the background cache warmer
+func handleCacheWarmStop() {
+	pid, err := internal.StopWarm()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Stopped cache warmer (pid %d)\n", pid)
+}
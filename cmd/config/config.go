@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	globalScope  bool
+	projectScope bool
+)
+
+// SkipConfigCheck is the annotation of commands that validate config files
+// themselves, so the root command does not warn about the same problems
+const SkipConfigCheck = "skipConfigCheck"
+
+// ConfigCmd represents the settings management command
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "⚙️  Read and edit settings",
+	Long: `⚙️  Configuration
+
+Read and change pcli settings without hand-editing config files. Settings
+live in two places:
+
+  global   your own config file (~/.pcli.json, .yaml or .toml, or --config)
+  project  the nearest .pcli.yaml (or .toml) in the working directory or a
+           parent, checked in with the code
+
+Project settings override global ones, environment variables like
+PCLI_AWS_REGION override both (see 'pcli config env'). Commands that change settings write
+to the project config when there is one and to the global config otherwise;
+--global or --project picks the file explicitly.
+
+Every key is checked against the list of supported settings and values are
+stored with their proper type (bool, number, duration, list).
+
+Available Commands:
+  get      🔍 Show the value of a setting
+  set      ✏️  Change a setting
+  unset    🗑️  Remove a setting
+  list     📋 List all settings
+  edit     📝 Open a config file in $EDITOR
+  path     📁 Show where the config files are
+  validate ✅ Check config files for errors
+  convert  🔄 Convert a config file to another format
+  env      🌱 List the environment variables pcli reads
+
+Examples:
+  pcli config get aws.region
+  pcli config set aws.region eu-west-1
+  pcli config set cache_ttl.log_groups 6h --global
+  pcli config unset cache_seed
+  pcli config edit --project
+  pcli config validate
+  pcli config convert --to yaml --global
+
+Use 'pcli config <command> --help' for more information about specific commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Display available subcommands and usage
+		fmt.Println("⚙️  Configuration Commands")
+		fmt.Println()
+		fmt.Println("Available commands:")
+		fmt.Println("  get      🔍 Show the value of a setting")
+		fmt.Println("  set      ✏️  Change a setting")
+		fmt.Println("  unset    🗑️  Remove a setting")
+		fmt.Println("  list     📋 List all settings")
+		fmt.Println("  edit     📝 Open a config file in $EDITOR")
+		fmt.Println("  path     📁 Show where the config files are")
+		fmt.Println("  validate ✅ Check config files for errors")
+		fmt.Println("  convert  🔄 Convert a config file to another format")
+		fmt.Println("  env      🌱 List the environment variables pcli reads")
+		fmt.Println()
+		fmt.Println("Use 'pcli config <command> --help' for more information.")
+	},
+}
+
+// scopePath returns the config file selected by --global/--project. Without
+// either flag it is the project config if there is one, else the global one.
+// With --project the file is named even when it does not exist yet.
+func scopePath() (path, scope string, err error) {
+	switch {
+	case globalScope && projectScope:
+		return "", "", fmt.Errorf("--global and --project cannot be combined")
+	case projectScope:
+		if path := internal.ProjectConfigPath(); path != "" {
+			return path, "project", nil
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(wd, internal.ProjectConfigName), "project", nil
+	case !globalScope:
+		if path := internal.ProjectConfigPath(); path != "" {
+			return path, "project", nil
+		}
+	}
+
+	path = viper.ConfigFileUsed()
+	if path == "" {
+		return "", "", fmt.Errorf("no global config file found")
+	}
+	return path, "global", nil
+}
+
+// completeConfigKeys completes supported setting names
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, k := range internal.ConfigKeys() {
+		keys = append(keys, k.Key)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	ConfigCmd.PersistentFlags().BoolVar(&globalScope, "global", false,
+		"🌐 Use the global config file")
+	ConfigCmd.PersistentFlags().BoolVar(&projectScope, "project", false,
+		"📂 Use the project config file (.pcli.yaml in the working directory)")
+}
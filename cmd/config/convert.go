@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+var convertTo string
+
+// convertCmd represents the command converting a config file to another format
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "🔄 Convert a config file to another format",
+	Long: `🔄 Convert Config
+
+Rewrite the project config if there is one, otherwise the global config (see
+--global and --project), in another format: JSON, YAML or TOML. The new file
+is written next to the old one (~/.pcli.json becomes ~/.pcli.yaml) and the
+old file is kept as <file>.bak.
+
+The order of settings is kept when converting between JSON and YAML. TOML is
+written with sorted keys, and comments do not survive a change of format.
+Project configs are YAML or TOML, since .pcli.json names the global config.
+
+Examples:
+  pcli config convert --to yaml --global
+  pcli config convert --to toml --project`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(convertTo)
+		if format == "yml" {
+			format = "yaml"
+		}
+
+		path, scope, err := scopePath()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		if scope == "project" && format == "json" {
+			fmt.Println("❌ Error: project configs must be YAML or TOML")
+			return
+		}
+
+		target, err := internal.ConvertConfigFile(path, format)
+		if err != nil {
+			fmt.Printf("❌ Error converting %s config: %v\n", scope, err)
+			return
+		}
+		fmt.Printf("✅ Converted %s config to %s (%s)\n", scope, format, target)
+		fmt.Printf("📦 The original is kept as %s.bak\n", path)
+		if scope == "global" && cmd.Flags().Changed("config") {
+			fmt.Printf("💡 Pass --config %s from now on\n", target)
+		}
+	},
+}
+
+// completeConfigFormats completes the supported config formats
+func completeConfigFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return internal.ConfigFormats, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	ConfigCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&convertTo, "to", "",
+		"🔄 Format to convert to (json, yaml, toml)")
+	convertCmd.MarkFlagRequired("to")
+	convertCmd.RegisterFlagCompletionFunc("to", completeConfigFormats)
+}
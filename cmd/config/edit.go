@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the command editing a config file in the user's editor
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "📝 Open a config file in $EDITOR",
+	Long: `📝 Edit Config
+
+Open the project config if there is one, otherwise the global config, in
+$VISUAL or $EDITOR (see --global and --project). The edited file is checked
+for syntax errors, unknown keys and wrong value types before it is saved;
+when it is invalid you can edit it again or discard the changes.
+
+Examples:
+  pcli config edit
+  EDITOR="code --wait" pcli config edit --global`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{SkipConfigCheck: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		path, scope, err := scopePath()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		original, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("❌ Error reading %s config: %v\n", scope, err)
+			return
+		}
+
+		// Edit a copy, so the real file only changes once the result is valid
+		tmp, err := os.CreateTemp("", "pcli-config-*"+filepath.Ext(path))
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(original)
+		tmp.Close()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		for {
+			if err := runEditor(tmp.Name()); err != nil {
+				fmt.Printf("❌ Error running editor: %v\n", err)
+				return
+			}
+
+			edited, err := os.ReadFile(tmp.Name())
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				return
+			}
+			if string(edited) == string(original) {
+				fmt.Println("ℹ️  No changes made")
+				return
+			}
+
+			problems := internal.ValidateConfigBytes(path, edited)
+			if len(problems) == 0 {
+				if err := internal.WriteConfigFile(path, edited); err != nil {
+					fmt.Printf("❌ Error saving config: %v\n", err)
+					return
+				}
+				fmt.Printf("✅ Saved %s config (%s)\n", scope, path)
+				return
+			}
+
+			fmt.Println("❌ The edited config is invalid:")
+			for _, problem := range problems {
+				problem.File = filepath.Base(path)
+				fmt.Printf("  • %s\n", problem)
+			}
+			if !confirm("Edit again?") {
+				fmt.Println("🗑️  Discarded changes; the config file was not modified")
+				return
+			}
+		}
+	},
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi (notepad on
+// Windows), and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Editors are often configured with arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	edit := exec.Command(parts[0], append(parts[1:], path)...)
+	edit.Stdin = os.Stdin
+	edit.Stdout = os.Stdout
+	edit.Stderr = os.Stderr
+	return edit.Run()
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to yes. Without
+// a terminal, or when input ends, the answer is no.
+func confirm(question string) bool {
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Printf("%s [Y/n] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+func init() {
+	ConfigCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// envCmd represents the command documenting the environment variables
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "🌱 List the environment variables pcli reads",
+	Long: `🌱 Environment Variables
+
+List every environment variable that overrides a setting, with the value it
+currently has. A variable is PCLI_ followed by the key in upper case with
+dots replaced by underscores, so aws.region is PCLI_AWS_REGION and
+cache_ttl.log_groups is PCLI_CACHE_TTL_LOG_GROUPS. Variables override both
+config files, while command line flags override variables.
+
+Lists are given space separated, e.g. PCLI_CACHE_WARM_CONTEXTS="staging prod".
+The config file version and contexts cannot be set through the environment,
+so PCLI_VERSION does not change the version pcli reports; that is built
+into the binary (see 'pcli --version').
+
+Examples:
+  pcli config env
+  PCLI_AWS_REGION=eu-west-1 pcli config list --show-origin`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"Variable", "Key", "Type", "Value", "Description"})
+
+		set := 0
+		for _, k := range internal.ConfigKeys() {
+			if k.NoEnv {
+				continue
+			}
+			name := internal.ConfigEnvVar(k.Key)
+			value := ""
+			if strings.Contains(name, "*") {
+				name = strings.Replace(name, "*", "<NAME>", 1)
+				value = wildcardEnvValues(internal.ConfigEnvVar(k.Key))
+			} else {
+				value = os.Getenv(name)
+			}
+			if value != "" {
+				set++
+			}
+			table.Append([]string{name, k.Key, string(k.Type), value, k.Description})
+		}
+		table.Render()
+
+		fmt.Printf("\n📊 %d variable(s) set\n", set)
+		fmt.Println("💡 Other variables pcli reads: VISUAL/EDITOR (config edit), XDG_CACHE_HOME and XDG_CONFIG_HOME (file locations), and the AWS_* variables of the AWS CLI")
+	},
+}
+
+// wildcardEnvValues lists the variables set for a wildcard pattern like
+// PCLI_CACHE_TTL_*, as NAME=value pairs
+func wildcardEnvValues(pattern string) string {
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	var values []string
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if value != "" && len(name) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			values = append(values, name+"="+value)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+func init() {
+	ConfigCmd.AddCommand(envCmd)
+}
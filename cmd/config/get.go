@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var getOutput string
+
+// getCmd represents the command showing a single setting
+var getCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "🔍 Show the value of a setting",
+	Long: `🔍 Get Setting
+
+Show the effective value of a setting, after project settings are merged over
+global ones. With --global or --project only that file is consulted. A section
+like "aws" or "contexts.prod" shows all settings below it.
+
+Examples:
+  pcli config get aws.region
+  pcli config get contexts.prod -o yaml
+  pcli config get cache_ttl.log_groups --global`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key := args[0]
+		if _, ok := internal.LookupConfigKey(key); !ok && !internal.IsConfigSection(key) {
+			fmt.Printf("❌ Error: %v\n", internal.UnknownConfigKeyError(key))
+			return
+		}
+
+		var (
+			value any
+			found bool
+		)
+		if globalScope || projectScope {
+			path, scope, err := scopePath()
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				return
+			}
+			data, err := internal.ReadConfigFile(path)
+			if err != nil {
+				fmt.Printf("❌ Error reading %s config: %v\n", scope, err)
+				return
+			}
+			value, found = internal.LookupConfigValue(data, key)
+		} else {
+			found = viper.IsSet(key)
+			value = viper.Get(key)
+		}
+
+		if !found {
+			fmt.Printf("⚠️  '%s' is not set\n", key)
+			return
+		}
+
+		formatted, err := internal.FormatValue(value, getOutput)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		fmt.Println(formatted)
+	},
+}
+
+func init() {
+	getCmd.Flags().StringVarP(&getOutput, "output", "o", "plain",
+		"📄 Output format: plain, json or yaml")
+	getCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(internal.OutputFormats, cobra.ShellCompDirectiveNoFileComp))
+	ConfigCmd.AddCommand(getCmd)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+var showOrigin bool
+
+// listCmd represents the command listing all settings
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "📋 List all settings",
+	Long: `📋 List Settings
+
+List every setting with its effective value and the layer it comes from.
+Layers override each other in this order:
+
+  default  built-in defaults
+  global   your own config file
+  project  the nearest .pcli.yaml in the working directory or its parents
+  env      environment variables (e.g. PCLI_AWS_REGION, see 'pcli config env')
+  flag     command line flags (--context, --profile, --region, ...)
+
+--show-origin also names the file, variable or flag of each value. With
+--global or --project only the settings of that file are listed.
+
+Examples:
+  pcli config list
+  pcli config list --show-origin
+  pcli config list --project`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var settings []internal.ConfigSetting
+
+		if globalScope || projectScope {
+			path, scope, err := scopePath()
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				return
+			}
+			data, err := internal.ReadConfigFile(path)
+			if err != nil {
+				fmt.Printf("❌ Error reading %s config: %v\n", scope, err)
+				return
+			}
+			for key, value := range internal.FlattenConfig(data) {
+				settings = append(settings, internal.ConfigSetting{Key: key, Value: value, Layer: scope, Source: path})
+			}
+			sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+		} else {
+			settings = internal.EffectiveConfig(cmd.Root().PersistentFlags())
+		}
+
+		if len(settings) == 0 {
+			fmt.Println("📋 No settings configured")
+			fmt.Println("Use 'pcli config set <key> <value>' to change one")
+			return
+		}
+
+		header := []string{"Key", "Value", "Scope"}
+		if showOrigin {
+			header = append(header, "Origin")
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header(header)
+		for _, s := range settings {
+			row := []string{s.Key, formatSetting(s.Value), s.Layer}
+			if showOrigin {
+				row = append(row, s.Source)
+			}
+			table.Append(row)
+		}
+		table.Render()
+		fmt.Printf("\n📊 Total settings: %d\n", len(settings))
+	},
+}
+
+// formatSetting renders a setting on a single line, lists comma separated
+func formatSetting(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+func init() {
+	ConfigCmd.AddCommand(listCmd)
+
+	listCmd.Flags().BoolVar(&showOrigin, "show-origin", false,
+		"🔎 Show the file, environment variable or flag each value comes from")
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pathCmd represents the command showing the config file locations
+var pathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "📁 Show where the config files are",
+	Long: `📁 Config Paths
+
+Show the global config file and the project config file, if there is one.
+With --global or --project only that path is printed, which is handy in
+scripts.
+
+Examples:
+  pcli config path
+  cat "$(pcli config path --global)"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if globalScope || projectScope {
+			path, _, err := scopePath()
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				return
+			}
+			fmt.Println(path)
+			return
+		}
+
+		fmt.Printf("🌐 Global:  %s\n", viper.ConfigFileUsed())
+		if path := internal.ProjectConfigPath(); path != "" {
+			fmt.Printf("📂 Project: %s\n", path)
+		} else {
+			fmt.Printf("📂 Project: none (create one with 'pcli config set <key> <value> --project')\n")
+		}
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(pathCmd)
+}
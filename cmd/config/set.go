@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// setCmd represents the command changing a setting
+var setCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "✏️  Change a setting",
+	Long: `✏️  Set Setting
+
+Change a setting in the project config if there is one, otherwise in the
+global config (see --global and --project). The key must be a supported
+setting and the value is stored with the key's type: true/false for
+switches, whole numbers, durations like 30s, 6h or 7d, and comma separated
+lists.
+
+Examples:
+  pcli config set aws.region eu-west-1
+  pcli config set cache_refresh.concurrency 8
+  pcli config set cache_ttl.log_groups 7d
+  pcli config set cache_warm.contexts staging,prod --global`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, raw := strings.ToLower(args[0]), args[1]
+
+		value, err := internal.ParseConfigValue(key, raw)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		path, scope, err := scopePath()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		if err := internal.SetConfigKeyIn(path, key, value); err != nil {
+			fmt.Printf("❌ Error saving setting: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Set %s = %v in %s config (%s)\n", key, value, scope, path)
+	},
+}
+
+func init() {
+	// List the supported settings in the help text
+	var keys strings.Builder
+	keys.WriteString("\n\nSupported settings:\n")
+	for _, k := range internal.ConfigKeys() {
+		fmt.Fprintf(&keys, "  %-28s %-9s %s\n", k.Key, k.Type, k.Description)
+	}
+	setCmd.Long += strings.TrimSuffix(keys.String(), "\n")
+
+	ConfigCmd.AddCommand(setCmd)
+}
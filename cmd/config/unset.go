@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// unsetCmd represents the command removing a setting
+var unsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "🗑️  Remove a setting",
+	Long: `🗑️  Unset Setting
+
+Remove a setting, or a whole section like "contexts.old", from the project
+config if there is one, otherwise from the global config (see --global and
+--project). The default or the value from the other file applies again.
+
+Examples:
+  pcli config unset cache_seed
+  pcli config unset aws.region --global`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key := strings.ToLower(args[0])
+		if _, ok := internal.LookupConfigKey(key); !ok && !internal.IsConfigSection(key) {
+			fmt.Printf("❌ Error: %v\n", internal.UnknownConfigKeyError(key))
+			return
+		}
+
+		path, scope, err := scopePath()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		data, err := internal.ReadConfigFile(path)
+		if err != nil {
+			fmt.Printf("❌ Error reading %s config: %v\n", scope, err)
+			return
+		}
+		if _, ok := internal.LookupConfigValue(data, key); !ok {
+			fmt.Printf("⚠️  '%s' is not set in %s config (%s)\n", key, scope, path)
+			return
+		}
+
+		if err := internal.DeleteConfigKeyIn(path, key); err != nil {
+			fmt.Printf("❌ Error removing setting: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Removed %s from %s config (%s)\n", key, scope, path)
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(unsetCmd)
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// validateCmd represents the command checking config files
+var validateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "✅ Check config files for errors",
+	Long: `✅ Validate Config
+
+Check config files for syntax errors, unknown keys and values of the wrong
+type, reporting each problem with its line number and, for misspelled keys,
+the closest supported key. Without arguments the global and project configs
+and the PCLI_ environment variables overriding settings are checked (or just
+one of the configs with --global or --project).
+
+The command exits with status 1 when a problem is found, so it can guard
+checked-in project configs in CI.
+
+Examples:
+  pcli config validate
+  pcli config validate --project
+  pcli config validate services/*/.pcli.yaml`,
+	Annotations: map[string]string{SkipConfigCheck: "true"},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		files := args
+		if len(files) == 0 {
+			if globalScope || projectScope {
+				path, _, err := scopePath()
+				if err != nil {
+					fmt.Printf("❌ Error: %v\n", err)
+					os.Exit(1)
+				}
+				files = []string{path}
+			} else {
+				for _, path := range []string{viper.ConfigFileUsed(), internal.ProjectConfigPath()} {
+					if path != "" {
+						files = append(files, path)
+					}
+				}
+			}
+		}
+
+		failed := 0
+		for _, path := range files {
+			problems, err := internal.ValidateConfigFile(path)
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", path, err)
+				failed++
+				continue
+			}
+			if len(problems) == 0 {
+				fmt.Printf("✅ %s is valid\n", path)
+				continue
+			}
+			failed++
+			fmt.Printf("❌ %s has %d problem(s):\n", path, len(problems))
+			for _, problem := range problems {
+				fmt.Printf("  %s\n", problem)
+			}
+		}
+
+		if len(args) == 0 && !globalScope && !projectScope {
+			if problems := internal.ValidateConfigEnv(); len(problems) > 0 {
+				failed++
+				fmt.Printf("❌ The environment has %d problem(s):\n", len(problems))
+				for _, problem := range problems {
+					fmt.Printf("  %s\n", problem)
+				}
+			}
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,124 @@
+package contexts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	roleARN        string
+	externalID     string
+	sessionName    string
+	mfaSerial      string
+	ssoStartURL    string
+	ssoRegion      string
+	ssoAccountID   string
+	ssoRoleName    string
+	logGroupPrefix string
+	source         string
+)
+
+// addCmd represents the command creating a new context
+var addCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "➕ Add a new context",
+	Long: `➕ Add Context
+
+Create a new context. The AWS profile and region are taken from the global
+--profile and --region flags; leave them out to use the AWS CLI defaults.
+
+With --role-arn, commands in this context assume the role using the profile's
+credentials. If --mfa-serial is set you are prompted for a token once; the
+temporary credentials are cached (readable only by you) under
+$XDG_CACHE_HOME/pcli/credentials until they expire.
+
+With --sso-account-id and --sso-role-name, credentials come from an IAM
+Identity Center session started with 'pcli auth login'.
+
+Examples:
+  pcli context add dev --profile dev --region us-east-1
+  pcli context add prod --profile prod --region eu-west-1 --log-group-prefix /prod/
+  pcli context add prod-admin --profile prod --role-arn arn:aws:iam::123456789012:role/Admin
+  pcli context add prod-mfa --profile base --role-arn arn:aws:iam::123456789012:role/ReadLogs \
+    --mfa-serial arn:aws:iam::111111111111:mfa/jane
+  pcli context add sso-dev --region us-east-1 --sso-start-url https://my-org.awsapps.com/start \
+    --sso-region us-east-1 --sso-account-id 123456789012 --sso-role-name ReadOnly`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := strings.ToLower(args[0])
+
+		// Dots would be split into nested keys by viper
+		if strings.ContainsAny(name, ". ") {
+			fmt.Printf("❌ Error: Invalid context name '%s' (dots and spaces are not allowed)\n", name)
+			return
+		}
+		if _, err := internal.GetContext(name); err == nil {
+			fmt.Printf("❌ Error: Context '%s' already exists\n", name)
+			fmt.Println("Use 'pcli context remove' first to replace it")
+			return
+		}
+		if roleARN == "" && (externalID != "" || sessionName != "" || mfaSerial != "") {
+			fmt.Println("❌ Error: --external-id, --session-name and --mfa-serial require --role-arn")
+			return
+		}
+		if (ssoAccountID == "") != (ssoRoleName == "") {
+			fmt.Println("❌ Error: --sso-account-id and --sso-role-name must be used together")
+			return
+		}
+		if err := internal.ValidateSource(source); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		ctx := internal.Context{
+			Name:           name,
+			Profile:        internal.Overrides.Profile,
+			Region:         internal.Overrides.Region,
+			RoleARN:        roleARN,
+			ExternalID:     externalID,
+			SessionName:    sessionName,
+			MFASerial:      mfaSerial,
+			SSOStartURL:    ssoStartURL,
+			SSORegion:      ssoRegion,
+			SSOAccountID:   ssoAccountID,
+			SSORoleName:    ssoRoleName,
+			LogGroupPrefix: logGroupPrefix,
+			Source:         source,
+		}
+		if err := internal.SetConfigKey("contexts."+name, ctx.Settings()); err != nil {
+			fmt.Printf("❌ Error adding context: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✅ Context '%s' added\n", name)
+		fmt.Printf("Use 'pcli context use %s' to switch to it\n", name)
+	},
+}
+
+func init() {
+	ContextCmd.AddCommand(addCmd)
+
+	addCmd.Flags().StringVar(&roleARN, "role-arn", "",
+		"🔑 IAM role to assume for this context")
+	addCmd.Flags().StringVar(&externalID, "external-id", "",
+		"🪪 External ID required by the role's trust policy")
+	addCmd.Flags().StringVar(&sessionName, "session-name", "",
+		"🏷️  Session name for the assumed role (default: pcli)")
+	addCmd.Flags().StringVar(&mfaSerial, "mfa-serial", "",
+		"🔐 MFA device ARN; prompts for a token when assuming the role")
+	addCmd.Flags().StringVar(&ssoStartURL, "sso-start-url", "",
+		"🌐 SSO start URL (default: sso.start_url)")
+	addCmd.Flags().StringVar(&ssoRegion, "sso-region", "",
+		"🌍 Region of the IAM Identity Center instance (default: sso.region)")
+	addCmd.Flags().StringVar(&ssoAccountID, "sso-account-id", "",
+		"🏢 Account to get SSO role credentials for")
+	addCmd.Flags().StringVar(&ssoRoleName, "sso-role-name", "",
+		"🎭 Permission set (role) to get SSO credentials for")
+	addCmd.Flags().StringVar(&logGroupPrefix, "log-group-prefix", "",
+		"📁 Default log group prefix, used to narrow completion")
+	addCmd.Flags().StringVar(&source, "source", internal.DefaultSource,
+		"📡 Log source backend")
+}
@@ -0,0 +1,52 @@
+package contexts
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ContextCmd represents the context management command
+var ContextCmd = &cobra.Command{
+	Use:     "context",
+	Aliases: []string{"ctx"},
+	Short:   "🧭 Manage named environments (contexts)",
+	Long: `🧭 Context Management
+
+A context bundles everything pcli needs to talk to one environment: the AWS
+profile and region, an optional role ARN, a default log group prefix and the
+log source backend. Switch between dev, staging and prod the same way you
+switch kubectl contexts.
+
+Every command uses the current context unless the global --context flag
+overrides it. The --profile and --region flags still take precedence over the
+values stored in the context.
+
+Available Commands:
+  list     📋 List all contexts
+  current  📍 Show the current context
+  use      🔀 Switch the current context
+  add      ➕ Add a new context
+  remove   🗑️  Remove a context
+
+Examples:
+  pcli context add prod --profile prod --region eu-west-1
+  pcli context use prod
+  pcli context list
+  pcli logs tail my-service --context staging
+
+Use 'pcli context <command> --help' for more information about specific commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Display available subcommands and usage
+		fmt.Println("🧭 Context Management Commands")
+		fmt.Println()
+		fmt.Println("Available commands:")
+		fmt.Println("  list     📋 List all contexts")
+		fmt.Println("  current  📍 Show the current context")
+		fmt.Println("  use      🔀 Switch the current context")
+		fmt.Println("  add      ➕ Add a new context")
+		fmt.Println("  remove   🗑️  Remove a context")
+		fmt.Println()
+		fmt.Println("Use 'pcli context <command> --help' for more information.")
+	},
+}
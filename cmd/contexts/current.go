@@ -0,0 +1,64 @@
+package contexts
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// currentCmd represents the command showing the active context
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "📍 Show the current context",
+	Long: `📍 Current Context
+
+Show the context commands run against, taking the global --context flag
+into account.
+
+Examples:
+  pcli context current`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := internal.CurrentContextName()
+		if name == "" {
+			fmt.Println("📍 No context in use")
+			fmt.Println("Use 'pcli context use <name>' to select one")
+			return
+		}
+
+		target, err := internal.ResolveTarget(name)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("📍 Current context: %s\n", name)
+		fmt.Printf("  Profile:          %s\n", valueOrDefault(target.Profile))
+		fmt.Printf("  Region:           %s\n", valueOrDefault(target.Region))
+		fmt.Printf("  Role ARN:         %s\n", valueOrDefault(target.RoleARN))
+		if target.RoleARN != "" {
+			fmt.Printf("  External ID:      %s\n", valueOrDefault(target.ExternalID))
+			fmt.Printf("  Session name:     %s\n", valueOrDefault(target.SessionName))
+			fmt.Printf("  MFA serial:       %s\n", valueOrDefault(target.MFASerial))
+		}
+		if target.UsesSSO() {
+			fmt.Printf("  SSO account:      %s\n", target.SSOAccountID)
+			fmt.Printf("  SSO role:         %s\n", target.SSORoleName)
+		}
+		fmt.Printf("  Log group prefix: %s\n", valueOrDefault(target.LogGroupPrefix))
+		fmt.Printf("  Source:           %s\n", target.Source)
+	},
+}
+
+// valueOrDefault renders unset context fields in a readable way
+func valueOrDefault(v string) string {
+	if v == "" {
+		return "(default)"
+	}
+	return v
+}
+
+func init() {
+	ContextCmd.AddCommand(currentCmd)
+}
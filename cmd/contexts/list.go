@@ -0,0 +1,69 @@
+package contexts
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the command listing all configured contexts
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "📋 List all contexts",
+	Long: `📋 List Contexts
+
+List all contexts defined in the config file. The current context is marked
+with an asterisk.
+
+Examples:
+  pcli context list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts, err := internal.Contexts()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		if len(contexts) == 0 {
+			fmt.Println("📋 No contexts configured")
+			fmt.Println("Use 'pcli context add <name>' to create one")
+			return
+		}
+
+		current := internal.CurrentContextName()
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"", "Name", "Profile", "Region", "Role ARN", "Log Group Prefix", "Source"})
+
+		for _, ctx := range contexts {
+			marker := ""
+			if ctx.Name == current {
+				marker = "*"
+			}
+			source := ctx.Source
+			if source == "" {
+				source = internal.DefaultSource
+			}
+			table.Append([]string{
+				marker,
+				ctx.Name,
+				ctx.Profile,
+				ctx.Region,
+				ctx.RoleARN,
+				ctx.LogGroupPrefix,
+				source,
+			})
+		}
+
+		table.Render()
+		fmt.Printf("\n📊 Total contexts: %d\n", len(contexts))
+	},
+}
+
+func init() {
+	ContextCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,49 @@
+package contexts
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// removeCmd represents the command deleting a context
+var removeCmd = &cobra.Command{
+	Use:     "remove [name]",
+	Aliases: []string{"rm"},
+	Short:   "🗑️  Remove a context",
+	Long: `🗑️  Remove Context
+
+Delete a context from the config file. Removing the current context leaves
+no context selected.
+
+Examples:
+  pcli context remove staging`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: internal.AutoCompleteContexts,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, err := internal.GetContext(args[0])
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		if err := internal.DeleteConfigKey("contexts." + ctx.Name); err != nil {
+			fmt.Printf("❌ Error removing context: %v\n", err)
+			return
+		}
+
+		if viper.GetString("current_context") == ctx.Name {
+			if err := internal.DeleteConfigKey("current_context"); err != nil {
+				fmt.Printf("⚠️  Warning: Could not reset current context: %v\n", err)
+			}
+		}
+
+		fmt.Printf("✅ Context '%s' removed\n", ctx.Name)
+	},
+}
+
+func init() {
+	ContextCmd.AddCommand(removeCmd)
+}
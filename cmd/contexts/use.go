@@ -0,0 +1,48 @@
+package contexts
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// useCmd represents the command switching the current context
+var useCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "🔀 Switch the current context",
+	Long: `🔀 Use Context
+
+Make the given context the current one. All following commands run against
+it until another context is selected.
+
+Examples:
+  pcli context use prod`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: internal.AutoCompleteContexts,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, err := internal.GetContext(args[0])
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			fmt.Println("Use 'pcli context list' to see available contexts")
+			return
+		}
+
+		if err := internal.SetConfigKey("current_context", ctx.Name); err != nil {
+			fmt.Printf("❌ Error switching context: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Switched to context '%s'\n", ctx.Name)
+
+		// A project config pins its own context, which wins inside the project
+		if project := internal.ProjectConfigPath(); project != "" && internal.ConfigFileDefining("current_context") == project {
+			fmt.Printf("⚠️  Warning: %s sets current_context to '%s', which still applies in this project\n",
+				project, viper.GetString("current_context"))
+		}
+	},
+}
+
+func init() {
+	ContextCmd.AddCommand(useCmd)
+}
@@ -2,6 +2,7 @@ package logs
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rashi1281/pcli/internal"
@@ -9,8 +10,10 @@ import (
 )
 
 var (
-	follow bool
-	since  time.Duration
+	follow   bool
+	since    time.Duration
+	regions  []string
+	contexts []string
 )
 
 // tailCmd represents the tail command for streaming logs
@@ -28,16 +31,26 @@ Features:
   ⚡ Auto-completion       - Tab completion for log group names
   📊 Clean formatting      - Formatted, readable log output
   🎯 Smart filtering       - Built-in log filtering and highlighting
+  🌍 Fan-out               - Tail several regions/contexts at once (--regions, --contexts)
 
 The command will automatically detect the log group and stream logs accordingly.
 Use Ctrl+C to stop streaming when using --follow mode.
 
+With --regions and/or --contexts the same tail runs in parallel against every
+target. Each line is tagged with its origin, results are merged in timestamp
+order, and a failing target is reported without aborting the others. Fan-out
+is only supported by 'logs tail' so far.
+
 Examples:
   pcli logs tail my-service                    # View recent logs
   pcli logs tail my-service --follow          # Stream logs in real-time
   pcli logs tail my-service --since 1h        # View logs from last hour
   pcli logs tail my-service -f -s 30m         # Stream logs from last 30 minutes
-  pcli logs tail /aws/lambda/my-function      # Stream Lambda function logs`,
+  pcli logs tail /aws/lambda/my-function      # Stream Lambda function logs
+  pcli logs tail my-service --profile prod --region eu-west-1
+  pcli logs tail my-service --context staging # Use a named context
+  pcli logs tail my-service --regions us-east-1,eu-west-1
+  pcli logs tail my-service --contexts prod-us,prod-eu -f`,
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: internal.AutoCompleteLogGroups,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -50,11 +63,23 @@ Examples:
 			return
 		}
 
+		// Fan out when several regions or contexts were requested
+		if len(regions) > 0 || len(contexts) > 0 {
+			tailFanOut(logGroup)
+			return
+		}
+
+		target, err := internal.CurrentTarget()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
 		// Display operation info
 		if follow {
-			fmt.Printf("🔄 Streaming logs from '%s' (Press Ctrl+C to stop)...\n", logGroup)
+			fmt.Printf("🔄 Streaming logs from '%s' using %s (Press Ctrl+C to stop)...\n", logGroup, target)
 		} else {
-			fmt.Printf("📊 Fetching logs from '%s'", logGroup)
+			fmt.Printf("📊 Fetching logs from '%s' using %s", logGroup, target)
 			if since > 0 {
 				fmt.Printf(" (since %v)", since)
 			}
@@ -63,7 +88,7 @@ Examples:
 		fmt.Println()
 
 		// Fetch and display logs
-		err := internal.GetLogs(logGroup, follow, since)
+		err = internal.GetLogs(target, logGroup, follow, since)
 		if err != nil {
 			fmt.Printf("❌ Error fetching logs: %v\n", err)
 			fmt.Println()
@@ -82,6 +107,39 @@ Examples:
 	},
 }
 
+// tailFanOut runs the tail against every requested region/context and
+// reports per-target failures
+func tailFanOut(logGroup string) {
+	origins, err := internal.FanOutTargets(contexts, regions)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	labels := make([]string, len(origins))
+	for i, origin := range origins {
+		labels[i] = origin.Label
+	}
+	if follow {
+		fmt.Printf("🔄 Streaming logs from '%s' on %s (Press Ctrl+C to stop)...\n", logGroup, strings.Join(labels, ", "))
+	} else {
+		fmt.Printf("📊 Fetching logs from '%s' on %s...\n", logGroup, strings.Join(labels, ", "))
+	}
+	fmt.Println()
+
+	errs := internal.GetLogsFanOut(origins, logGroup, follow, since)
+
+	fmt.Println()
+	if len(errs) == 0 {
+		fmt.Printf("✅ Log fetch completed on %d targets\n", len(origins))
+		return
+	}
+	fmt.Printf("⚠️  Log fetch completed with %d/%d targets failing:\n", len(errs), len(origins))
+	for _, err := range errs {
+		fmt.Printf("  • %v\n", err)
+	}
+}
+
 func init() {
 	// Add tail command to logs command
 	LogsCmd.AddCommand(tailCmd)
@@ -92,4 +150,11 @@ func init() {
 
 	tailCmd.Flags().DurationVarP(&since, "since", "s", 0*time.Hour,
 		"📅 How far back to fetch logs (e.g. 10m, 1h, 24h). Ignored with --follow")
+
+	tailCmd.Flags().StringSliceVar(&regions, "regions", nil,
+		"🌍 Tail these regions in parallel (e.g. us-east-1,eu-west-1)")
+
+	tailCmd.Flags().StringSliceVar(&contexts, "contexts", nil,
+		"🧭 Tail these contexts in parallel (e.g. prod-us,prod-eu)")
+	tailCmd.RegisterFlagCompletionFunc("contexts", internal.AutoCompleteContexts)
 }
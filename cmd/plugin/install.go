@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installName  string
+	installForce bool
+)
+
+// installCmd represents the command installing a plugin from a local file
+var installCmd = &cobra.Command{
+	Use:   "install [file]",
+	Short: "📥 Install a plugin from a local file",
+	Long: `📥 Install Plugin
+
+Copy an executable into ~/.pcli/plugins, where it becomes a pcli command.
+The command is named after the file without its pcli- prefix and extension
+(pcli-deploy.sh becomes 'pcli deploy') unless --name is given.
+
+Examples:
+  pcli plugin install ./bin/pcli-deploy
+  pcli plugin install ./scripts/rollout.sh --name rollout
+  pcli plugin install ./bin/pcli-deploy --force`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := internal.InstallPlugin(args[0], installName, installForce)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Installed plugin '%s' (%s)\n", p.Name, p.Path)
+
+		if internal.IsBuiltinCommand(p.Name) {
+			fmt.Printf("⚠️  Warning: '%s' is a built-in command, so the plugin will not run\n", p.Name)
+			return
+		}
+		fmt.Printf("Run it with 'pcli %s'\n", p.Name)
+	},
+}
+
+func init() {
+	PluginCmd.AddCommand(installCmd)
+
+	installCmd.Flags().StringVar(&installName, "name", "",
+		"🏷️  Command name of the plugin (default: from the file name)")
+	installCmd.Flags().BoolVar(&installForce, "force", false,
+		"💪 Replace an installed plugin of the same name")
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the command listing all plugins
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "📋 List all plugins",
+	Long: `📋 List Plugins
+
+List the plugins found in ~/.pcli/plugins and on PATH with the description
+and version they report. Plugins named like a built-in command, or hidden by
+another plugin of the same name earlier in the search order, are not listed
+as active.
+
+Examples:
+  pcli plugin list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		plugins := internal.DiscoverPlugins()
+		if len(plugins) == 0 {
+			fmt.Println("🔌 No plugins found")
+			fmt.Println("Put a pcli-<name> executable on PATH or use 'pcli plugin install <file>'")
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"Name", "Description", "Version", "Source", "Path"})
+		for _, p := range plugins {
+			description, version := "", ""
+			if desc, err := p.Describe(); err == nil {
+				description, version = desc.Short, desc.Version
+			}
+
+			source := "PATH"
+			if p.Installed {
+				source = "installed"
+			}
+			if c, _, err := cmd.Root().Find([]string{p.Name}); err != nil || !IsPluginCommand(c) {
+				source += " (⚠️  shadowed by built-in)"
+			}
+			table.Append([]string{p.Name, description, version, source, p.Path})
+		}
+		table.Render()
+		fmt.Printf("\n📊 Total plugins: %d\n", len(plugins))
+	},
+}
+
+func init() {
+	PluginCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,246 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rashi1281/pcli/cmd/alias"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// pluginPath is the annotation holding the executable of a plugin command
+const pluginPath = "pcliPluginPath"
+
+// PluginCmd represents the plugin management command
+var PluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "🔌 Manage plugins",
+	Long: `🔌 Plugins
+
+Plugins add commands to pcli without changing it. Any executable named
+pcli-<name> in ~/.pcli/plugins or on your PATH becomes 'pcli <name>'; plugins
+in ~/.pcli/plugins win over those on PATH, and built-in commands always win.
+
+Plugins run with the resolved target in their environment: PCLI_CONTEXT,
+PCLI_PROFILE, PCLI_REGION, PCLI_CONFIG and PCLI_BIN, plus AWS_PROFILE or the
+temporary credentials of the context's role or SSO sign-in, and AWS_REGION.
+Global flags like --context go before the plugin name; everything after it
+is passed to the plugin unchanged.
+
+A plugin can describe itself for help and completion: run with
+--pcli-describe it prints JSON like
+
+  {"short": "Deploy a service", "usage": "deploy <service>",
+   "args": ["api", "worker"], "flags": ["--dry-run"], "complete": false}
+
+With "complete": true pcli instead runs 'pcli-<name> --pcli-complete
+<args...> <word>' and offers the lines it prints.
+
+Available Commands:
+  list     📋 List all plugins
+  install  📥 Install a plugin from a local file
+  remove   🗑️  Remove an installed plugin
+
+Examples:
+  pcli plugin install ./bin/pcli-deploy
+  pcli plugin list
+  pcli --context prod deploy api
+
+Use 'pcli plugin <command> --help' for more information about specific commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Display available subcommands and usage
+		fmt.Println("🔌 Plugin Commands")
+		fmt.Println()
+		fmt.Println("Available commands:")
+		fmt.Println("  list     📋 List all plugins")
+		fmt.Println("  install  📥 Install a plugin from a local file")
+		fmt.Println("  remove   🗑️  Remove an installed plugin")
+		fmt.Println()
+		fmt.Println("Use 'pcli plugin <command> --help' for more information.")
+	},
+}
+
+// Register adds a command to root for every plugin whose name is not taken
+// by a built-in command and returns the names of the plugins added
+func Register(root *cobra.Command) []string {
+	taken := map[string]bool{}
+	for _, name := range alias.BuiltinNames(root) {
+		taken[name] = true
+	}
+	var names []string
+	for _, p := range internal.DiscoverPlugins() {
+		if !taken[p.Name] {
+			root.AddCommand(newPluginCommand(p))
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// shadowReports are the subcommands that report which commands are
+// shadowed, by the command they belong to
+var shadowReports = map[string]string{
+	"plugin": "list",
+	"alias":  "list",
+	"config": "validate",
+}
+
+// Needed reports whether the command line args (os.Args[1:]) needs the
+// plugin commands: to run a plugin, or an alias since plugins win over
+// aliases, for the help of pcli itself, to complete command names and for
+// the commands reporting shadowed commands. Discovery reads every PATH
+// directory, which built-in commands and their completion are spared.
+func Needed(root *cobra.Command, args []string) bool {
+	completing := len(args) > 0 &&
+		(args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+	if completing {
+		args = args[1:]
+	}
+	i, _ := alias.CommandWord(root, args)
+	if i < 0 || completing && i == len(args)-1 {
+		return true
+	}
+
+	name := strings.ToLower(args[i])
+	if name == "help" {
+		return true
+	}
+	if sub, ok := shadowReports[name]; ok {
+		return i+1 < len(args) && args[i+1] == sub
+	}
+	return !internal.IsBuiltinCommand(name)
+}
+
+// newPluginCommand builds the command running a plugin. Flags are not
+// parsed, so the plugin gets its arguments exactly as typed; help and
+// completion ask the plugin only when they are needed.
+func newPluginCommand(p internal.Plugin) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                p.Name,
+		Short:              fmt.Sprintf("🔌 Plugin (%s)", p.Path),
+		DisableFlagParsing: true,
+		Annotations:        map[string]string{pluginPath: p.Path},
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(runPlugin(p, args))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completePlugin(p, args, toComplete)
+		},
+	}
+	cmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		showPluginHelp(p)
+	})
+	return cmd
+}
+
+// IsPluginCommand reports whether cmd runs a plugin
+func IsPluginCommand(cmd *cobra.Command) bool {
+	return cmd.Annotations[pluginPath] != ""
+}
+
+// PrepareArgs handles pcli's global flags given before a plugin name, since
+// plugin commands leave flag parsing to the plugin. Other command lines are
+// returned unchanged.
+func PrepareArgs(root *cobra.Command, args []string) ([]string, error) {
+	i, _ := alias.CommandWord(root, args)
+	if i < 0 {
+		return args, nil
+	}
+	cmd, _, err := root.Find(args[i : i+1])
+	if err != nil || cmd == root || !IsPluginCommand(cmd) {
+		return args, nil
+	}
+	if err := root.PersistentFlags().Parse(args[:i]); err != nil {
+		return nil, err
+	}
+	return args[i:], nil
+}
+
+// runPlugin runs a plugin against the current target and returns its exit
+// code
+func runPlugin(p internal.Plugin, args []string) int {
+	target, err := internal.CurrentTarget()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return 1
+	}
+	env, err := internal.PluginEnv(target)
+	if err != nil {
+		fmt.Printf("❌ Error getting credentials: %v\n", err)
+		return 1
+	}
+
+	cmd := exec.Command(p.Path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Printf("❌ Error running plugin '%s': %v\n", p.Name, err)
+		return 1
+	}
+	return 0
+}
+
+// showPluginHelp prints the help a plugin describes, or how to get it
+func showPluginHelp(p internal.Plugin) {
+	desc, err := p.Describe()
+	if err != nil {
+		fmt.Printf("🔌 %s is provided by the plugin %s\n", p.Name, p.Path)
+		fmt.Printf("Run 'pcli %s --help' for its own help\n", p.Name)
+		return
+	}
+
+	if desc.Short != "" {
+		fmt.Printf("🔌 %s\n\n", desc.Short)
+	}
+	if desc.Long != "" {
+		fmt.Printf("%s\n\n", strings.TrimRight(desc.Long, "\n"))
+	}
+	usage := desc.Usage
+	if usage == "" {
+		usage = p.Name + " [args]"
+	}
+	fmt.Printf("Usage:\n  pcli %s\n\n", usage)
+	if len(desc.Flags) > 0 {
+		fmt.Printf("Flags:\n  %s\n\n", strings.Join(desc.Flags, "\n  "))
+	}
+	fmt.Printf("Plugin: %s", p.Path)
+	if desc.Version != "" {
+		fmt.Printf(" (%s)", desc.Version)
+	}
+	fmt.Println()
+}
+
+// completePlugin completes plugin arguments from its description, or by
+// asking the plugin when it supports --pcli-complete
+func completePlugin(p internal.Plugin, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	desc, err := p.Describe()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if desc.Complete {
+		candidates, err := p.Completions(args, toComplete)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return candidates, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	if strings.HasPrefix(toComplete, "-") {
+		candidates = desc.Flags
+	} else if len(args) == 0 {
+		candidates = desc.Args
+	}
+	if len(candidates) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rashi1281/pcli/cmd/alias"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// testRoot returns a root command like pcli's, with the built-in logs,
+// plugin and config commands and the deploy plugin.
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "pcli"}
+	root.PersistentFlags().String("context", "", "")
+	root.PersistentFlags().BoolP("quiet", "q", false, "")
+	root.AddCommand(&cobra.Command{Use: "logs"}, &cobra.Command{Use: "plugin"}, &cobra.Command{Use: "config"})
+	internal.SetBuiltinCommands(alias.BuiltinNames(root))
+	root.AddCommand(newPluginCommand(internal.Plugin{Name: "deploy", Path: "/bin/pcli-deploy"}))
+	return root
+}
+
+func TestNeeded(t *testing.T) {
+	root := testRoot()
+	tests := map[string]bool{
+		"":                                  true,
+		"--help":                            true,
+		"help logs":                         true,
+		"logs tail api":                     false,
+		"--context prod logs tail":          false,
+		"deploy api":                        true,
+		"--context prod errs api":           true,
+		"plugin list":                       true,
+		"plugin remove deploy":              false,
+		"config validate":                   true,
+		"config get aws.region":             false,
+		"__complete lo":                     true,
+		"__completeNoDesc --context prod d": true,
+		"__complete logs tail ":             false,
+		"__complete deploy ":                true,
+	}
+	for line, want := range tests {
+		var args []string
+		if line != "" {
+			args = strings.Split(line, " ")
+		}
+		if got := Needed(root, args); got != want {
+			t.Errorf("Needed(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestPrepareArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    string
+		context string
+	}{
+		{args: "deploy --context dev api", want: "deploy --context dev api"},
+		{args: "--context prod deploy --dry-run api", want: "deploy --dry-run api", context: "prod"},
+		{args: "-q --context=stage deploy", want: "deploy", context: "stage"},
+		{args: "--context prod logs tail api", want: "--context prod logs tail api"},
+		{args: "--unknown deploy", want: "--unknown deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			root := testRoot()
+			got, err := PrepareArgs(root, strings.Fields(tt.args))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("args = %q, want %q", strings.Join(got, " "), tt.want)
+			}
+			if context, _ := root.PersistentFlags().GetString("context"); context != tt.context {
+				t.Errorf("--context = %q, want %q", context, tt.context)
+			}
+		})
+	}
+}
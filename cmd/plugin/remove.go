@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+)
+
+// removeCmd represents the command removing an installed plugin
+var removeCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "🗑️  Remove an installed plugin",
+	Long: `🗑️  Remove Plugin
+
+Delete a plugin installed with 'pcli plugin install' from ~/.pcli/plugins.
+Plugins found on PATH are left alone.
+
+Examples:
+  pcli plugin remove deploy`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, p := range internal.DiscoverPlugins() {
+			if p.Installed {
+				names = append(names, p.Name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := internal.RemovePlugin(args[0])
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Removed plugin '%s' (%s)\n", p.Name, p.Path)
+	},
+}
+
+func init() {
+	PluginCmd.AddCommand(removeCmd)
+}
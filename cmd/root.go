@@ -4,17 +4,34 @@ Copyright © 2025 Rashi M
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
+	"github.com/rashi1281/pcli/cmd/alias"
+	"github.com/rashi1281/pcli/cmd/auth"
 	"github.com/rashi1281/pcli/cmd/cache"
+	"github.com/rashi1281/pcli/cmd/config"
+	"github.com/rashi1281/pcli/cmd/contexts"
 	"github.com/rashi1281/pcli/cmd/logs"
+	"github.com/rashi1281/pcli/cmd/plugin"
+	"github.com/rashi1281/pcli/cmd/version"
+	"github.com/rashi1281/pcli/internal"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
 
+// configLoaded and projectLoaded record which config files initConfig read
+// without parse errors, so PersistentPreRun can validate their settings
+var configLoaded, projectLoaded bool
+
+// completing is set while cobra's hidden __complete command answers a shell
+// completion request, whose stdout the shell reads as candidates
+var completing bool
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "pcli",
@@ -28,6 +45,11 @@ and improve team efficiency.
 Features:
   📋 Log Management    - View and stream application logs
   💾 Cache Management  - Manage CLI cache and data
+  🧭 Contexts         - Switch between named environments
+  🔐 SSO Login        - Sign in with AWS IAM Identity Center
+  ⚙️  Configuration    - Read and edit settings per user or project
+  🔗 Aliases          - Shortcuts and macros for your own workflows
+  🔌 Plugins          - Extra commands from pcli-* executables
   🔧 AWS Integration  - Seamless AWS service integration
   ⚡ Auto-completion  - Smart command completion
 
@@ -35,6 +57,7 @@ Examples:
   pcli logs tail my-service --follow
   pcli cache refresh
   pcli cache list
+  pcli context use staging
   pcli --help
 
 For more information about a specific command, use:
@@ -46,6 +69,12 @@ For more information about a specific command, use:
 		fmt.Println("Available commands:")
 		fmt.Println("  logs    📋 View and stream application logs")
 		fmt.Println("  cache   💾 Manage CLI cache and data")
+		fmt.Println("  context 🧭 Manage named environments")
+		fmt.Println("  auth    🔐 Sign in with AWS SSO")
+		fmt.Println("  config  ⚙️  Read and edit settings")
+		fmt.Println("  alias   🔗 Manage command aliases and macros")
+		fmt.Println("  plugin  🔌 Manage plugins")
+		fmt.Println("  version 📋 Show version and build information")
 		fmt.Println()
 		fmt.Println("Use 'pcli <command> --help' for more information about a command.")
 		fmt.Println("Use 'pcli --help' to see all available options.")
@@ -55,7 +84,31 @@ For more information about a specific command, use:
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// pcli-* executables on PATH and in ~/.pcli/plugins become commands,
+	// which win over aliases too
+	if plugin.Needed(rootCmd, os.Args[1:]) {
+		internal.SetPluginCommands(plugin.Register(rootCmd))
+	}
+
+	// Aliases and macros from the config stand in for the commands they run
+	args, macro, err := alias.ExpandArgs(rootCmd, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if macro != nil {
+		os.Exit(alias.RunMacro(*macro))
+	}
+	// Plugins parse their own flags; global flags before their name are ours
+	if args, err = plugin.PrepareArgs(rootCmd, args); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	rootCmd.SetArgs(args)
+	completing = len(args) > 0 &&
+		(args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+
+	err = rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -66,10 +119,20 @@ func init() {
 
 	rootCmd.AddCommand(logs.LogsCmd)
 	rootCmd.AddCommand(cache.CacheCmd)
+	rootCmd.AddCommand(contexts.ContextCmd)
+	rootCmd.AddCommand(auth.AuthCmd)
+	rootCmd.AddCommand(config.ConfigCmd)
+	rootCmd.AddCommand(alias.AliasCmd)
+	rootCmd.AddCommand(plugin.PluginCmd)
+	rootCmd.AddCommand(version.VersionCmd)
+
+	// Aliases must not shadow these; the alias names complete next to them
+	internal.SetBuiltinCommands(alias.BuiltinNames(rootCmd))
+	rootCmd.ValidArgsFunction = alias.Complete
 
 	// Global configuration flags available to all commands
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "",
-		"📁 Config file path (default: $HOME/.pcli.json)")
+		"📁 Config file path, JSON, YAML or TOML (default: $HOME/.pcli.json)")
 
 	// Add version flag
 	rootCmd.Flags().BoolP("version", "v", false, "📋 Show version information")
@@ -80,21 +143,53 @@ func init() {
 	// Add quiet flag for minimal output
 	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "🔇 Suppress non-essential output")
 
+	// AWS targeting flags, overriding the current context and aws.profile/aws.region
+	rootCmd.PersistentFlags().StringVar(&internal.Overrides.Context, "context", "",
+		"🧭 Context to use (default: current_context from config)")
+	rootCmd.PersistentFlags().StringVar(&internal.Overrides.Profile, "profile", "",
+		"👤 AWS profile to use (default: from context or aws.profile)")
+	rootCmd.PersistentFlags().StringVar(&internal.Overrides.Region, "region", "",
+		"🌍 AWS region to use (default: from context or aws.region)")
+	rootCmd.RegisterFlagCompletionFunc("context", internal.AutoCompleteContexts)
+
+	// Cache freshness override, e.g. to force completion to refresh sooner
+	rootCmd.PersistentFlags().Var(internal.DurationFlag{D: &internal.CacheMaxAge}, "max-age",
+		"⏱️  Treat cached data older than this as stale, e.g. 30m or 2d (default: per-key TTL)")
+
 	// Bind flags to viper so initConfig can respect --quiet/--verbose
 	viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 
+	// Point out unknown keys and invalid values before any command runs;
+	// parse errors were reported by initConfig already. Commands that report
+	// problems themselves opt out with the skipConfigCheck annotation.
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if configLoaded {
+			warnNewerConfig(viper.ConfigFileUsed())
+		}
+		if cmd.Annotations[config.SkipConfigCheck] != "" {
+			return
+		}
+		if configLoaded {
+			warnConfigProblems(viper.ConfigFileUsed())
+		}
+		if projectLoaded {
+			warnConfigProblems(internal.ProjectConfigPath())
+		}
+		warnEnvProblems()
+	}
+
 	// Handle --version: print version and exit before running commands
 	rootCmd.PreRun = func(cmd *cobra.Command, args []string) {
 		showVersion, _ := cmd.Flags().GetBool("version")
 		if showVersion {
-			// Version could be set at build time via -ldflags "-X main.version=..."
-			// Fallback to 'dev' if not set
-			version := os.Getenv("PCLI_VERSION")
-			if version == "" {
-				version = "dev"
+			// Set at build time via -ldflags, see 'pcli version' for details
+			info := internal.ReadBuildInfo()
+			if commit := info.ShortCommit(); commit != "" {
+				fmt.Printf("pcli version %s (%s)\n", info.Version, commit)
+			} else {
+				fmt.Printf("pcli version %s\n", info.Version)
 			}
-			fmt.Printf("pcli version %s\n", version)
 			os.Exit(0)
 		}
 	}
@@ -115,39 +210,133 @@ func initConfig() {
 			return
 		}
 
-		// Search for config file in home directory with name ".pcli.json"
-		viper.AddConfigPath(home)
-		viper.SetConfigType("json")
-		viper.SetConfigName(".pcli")
+		// Use ~/.pcli.json, .pcli.yaml, .pcli.yml or .pcli.toml, whichever
+		// exists first; a new config file is created as ~/.pcli.json. The
+		// format always follows the file extension.
+		viper.SetConfigFile(internal.FindConfigFile(home, ".pcli"))
 	}
 
-	// Enable automatic environment variable reading
-	// Environment variables will override config file values
-	viper.AutomaticEnv()
-
 	// Try to read the configuration file
 	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) || (cfgFile == "" && errors.Is(err, fs.ErrNotExist)) {
 			// Config file not found, create a default one
 			if !viper.GetBool("quiet") {
 				fmt.Println("📁 Config file not found, creating default configuration...")
 			}
 
 			// Set some default values
-			viper.SetDefault("version", "v0.1.0")
+			viper.SetDefault("version", internal.ConfigVersion)
+			viper.SetDefault("aws.profile", "")
+			viper.SetDefault("aws.region", "")
 
 			// Write the default config file
-			if err := viper.SafeWriteConfig(); err != nil {
+			if err := viper.SafeWriteConfigAs(viper.ConfigFileUsed()); err != nil {
 				fmt.Printf("⚠️  Warning: Could not create config file: %v\n", err)
-			} else if !viper.GetBool("quiet") {
-				fmt.Println("✅ Default configuration created successfully")
+			} else {
+				// Bind the new file so commands can persist changes to it
+				viper.ReadInConfig()
+				if !viper.GetBool("quiet") {
+					fmt.Println("✅ Default configuration created successfully")
+				}
 			}
 		} else {
 			// Other configuration errors
 			fmt.Printf("⚠️  Warning: Error reading config file: %v\n", err)
 		}
-	} else if viper.GetBool("verbose") {
-		// Config file loaded successfully
-		fmt.Printf("📁 Using config file: %s\n", viper.ConfigFileUsed())
+	} else {
+		configLoaded = true
+		if viper.GetBool("verbose") {
+			// Config file loaded successfully
+			fmt.Printf("📁 Using config file: %s\n", viper.ConfigFileUsed())
+		}
+	}
+
+	// Built-in defaults sit below every file; registered after the read so a
+	// newly created config file does not have them written into it
+	internal.ApplyConfigDefaults()
+
+	// Environment variables override config file values: PCLI_ followed by
+	// the key with dots as underscores, e.g. PCLI_AWS_REGION. Bound after
+	// the read for the same reason as the defaults.
+	internal.BindConfigEnv()
+
+	// Settings checked into the nearest project config override the user's own
+	if err := internal.MergeProjectConfig(); err != nil {
+		fmt.Printf("⚠️  Warning: Error reading project config: %v\n", err)
+	} else {
+		projectLoaded = internal.ProjectConfigPath() != ""
+	}
+
+	// Upgrade a config file written by an older pcli, once; files of a newer
+	// pcli are left alone and PersistentPreRun warns about them. Completion
+	// leaves it to the next command, so the summary is not lost.
+	if configLoaded && !completing {
+		if result, err := internal.MigrateConfig(viper.ConfigFileUsed()); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: Could not migrate config file: %v\n", err)
+		} else if result != nil && len(result.Changes) > 0 && !viper.GetBool("quiet") {
+			from := result.From
+			if from == "" {
+				from = "an unversioned config"
+			}
+			fmt.Fprintf(os.Stderr, "📦 Migrated %s from %s to %s", result.Path, from, result.To)
+			if result.Backup != "" {
+				fmt.Fprintf(os.Stderr, " (backup: %s)", result.Backup)
+			}
+			fmt.Fprintln(os.Stderr)
+			for _, change := range result.Changes {
+				fmt.Fprintf(os.Stderr, "  • %s\n", change)
+			}
+		}
+	}
+
+	// Warm the cache from the team's seed file, if one is configured; once
+	// per version of the file, and never while completing
+	if target, err := internal.CurrentTarget(); err == nil && !completing {
+		if path, err := internal.AutoImportSeed(target); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: Could not import cache seed: %v\n", err)
+		} else if path != "" && !viper.GetBool("quiet") {
+			fmt.Fprintf(os.Stderr, "📥 Imported cache seed from %s\n", path)
+		}
+	}
+
+	// Show which environment commands will run against
+	if viper.GetBool("verbose") {
+		if target, err := internal.CurrentTarget(); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+		} else {
+			fmt.Printf("🧭 Using %s\n", target)
+		}
+	}
+}
+
+// warnConfigProblems validates a config file and prints its problems as
+// warnings. They go to stderr, so shell completion output stays intact.
+func warnConfigProblems(path string) {
+	problems, err := internal.ValidateConfigFile(path)
+	if err != nil {
+		return
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(os.Stderr, "Run 'pcli config validate' for details or 'pcli config edit' to fix them")
+	}
+}
+
+// warnEnvProblems points out PCLI_ variables with invalid values
+func warnEnvProblems() {
+	for _, problem := range internal.ValidateConfigEnv() {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", problem)
+	}
+}
+
+// warnNewerConfig warns when the config file was written by a newer pcli,
+// whose settings this one may not understand
+func warnNewerConfig(path string) {
+	if newer := internal.NewerConfigVersion(path); newer != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s was written by a newer pcli (config version %s, this pcli supports %s); please upgrade pcli\n",
+			path, newer, internal.ConfigVersion)
 	}
 }
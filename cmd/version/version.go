@@ -0,0 +1,135 @@
+package version
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/rashi1281/pcli/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var outputFormat string
+
+// versionReport is what 'pcli version -o json|yaml' prints
+type versionReport struct {
+	internal.BuildInfo `yaml:",inline"`
+	Config             *configReport `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// configReport describes the version of the loaded config file
+type configReport struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Newer is set when a newer pcli wrote the file
+	Newer bool `json:"newer,omitempty" yaml:"newer,omitempty"`
+}
+
+// VersionCmd represents the command showing build information
+var VersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "📋 Show version and build information",
+	Long: `📋 Version
+
+Show the version of pcli with the git commit and date it was built from, the
+Go version, the config file version it writes and the modules compiled into
+it. Include this output in bug reports.
+
+Release builds set the version, commit and build date with -ldflags;
+binaries built with 'go build' or 'go install' report the commit and date
+the go command recorded, and "dev" as version when there is no release tag.
+
+When the config file was written by a newer pcli, which this one may not
+fully understand, the version says so; other commands print a warning.
+
+Examples:
+  pcli version
+  pcli version -o json | jq -r .commit`,
+	Args: cobra.NoArgs,
+	// Skips the config checks of the root command, the config version is
+	// part of the report
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		report := versionReport{BuildInfo: internal.ReadBuildInfo()}
+		if path := viper.ConfigFileUsed(); path != "" {
+			if data, err := internal.ReadConfigFile(path); err == nil {
+				version, _ := data["version"].(string)
+				report.Config = &configReport{
+					Path:    path,
+					Version: version,
+					Newer:   internal.NewerConfigVersion(path) != "",
+				}
+			}
+		}
+
+		switch outputFormat {
+		case "", "table":
+		case "json", "yaml":
+			out, err := internal.FormatValue(report, outputFormat)
+			if err != nil {
+				fmt.Printf("❌ Error formatting version: %v\n", err)
+				return
+			}
+			fmt.Println(out)
+			return
+		default:
+			fmt.Printf("❌ Error: Unsupported output format '%s' (supported: table, json, yaml)\n", outputFormat)
+			return
+		}
+
+		showReport(report)
+	},
+}
+
+// showReport prints the version report for humans
+func showReport(report versionReport) {
+	info := report.BuildInfo
+	fmt.Printf("📋 pcli %s\n\n", info.Version)
+
+	commit := info.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if info.Modified {
+		commit += " (modified)"
+	}
+	buildDate := info.BuildDate
+	if buildDate == "" {
+		buildDate = "unknown"
+	}
+	fmt.Printf("  Commit:         %s\n", commit)
+	fmt.Printf("  Built:          %s\n", buildDate)
+	fmt.Printf("  Go:             %s\n", info.GoVersion)
+	fmt.Printf("  Platform:       %s\n", info.Platform)
+	fmt.Printf("  Config version: %s\n", info.ConfigVersion)
+
+	if report.Config != nil {
+		version := report.Config.Version
+		if version == "" {
+			version = "unversioned"
+		}
+		fmt.Printf("  Config file:    %s (%s)\n", report.Config.Path, version)
+		if report.Config.Newer {
+			fmt.Printf("\n⚠️  %s was written by a newer pcli (config version %s); upgrade pcli to use all of its settings\n",
+				report.Config.Path, report.Config.Version)
+		}
+	}
+
+	if len(info.Deps) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("📦 Modules:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header([]string{"Module", "Version", "Replaced By"})
+	for _, dep := range info.Deps {
+		table.Append([]string{dep.Path, dep.Version, dep.Replace})
+	}
+	table.Render()
+}
+
+func init() {
+	VersionCmd.Flags().StringVarP(&outputFormat, "output", "o", "",
+		"📄 Output format: table, json or yaml")
+	VersionCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+}
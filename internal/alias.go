@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Alias is a user-defined command: a command line from aliases.<name>, or
+// the steps of a macro from macros.<name>, run one after the other.
+type Alias struct {
+	Name  string
+	Steps []string
+	Macro bool
+	// Scope is "global" or "project", Source the file defining the alias
+	Scope  string
+	Source string
+}
+
+// builtinCommands are the names of pcli's own commands, which aliases
+// cannot shadow.
+var builtinCommands = map[string]bool{}
+
+// SetBuiltinCommands records the names of pcli's own commands, so aliases
+// and macros named like one are reported and ignored.
+func SetBuiltinCommands(names []string) {
+	for _, name := range names {
+		builtinCommands[strings.ToLower(name)] = true
+	}
+}
+
+// IsBuiltinCommand reports whether name is one of pcli's own commands.
+func IsBuiltinCommand(name string) bool {
+	return builtinCommands[strings.ToLower(name)]
+}
+
+// pluginCommands are the names of the plugins registered as commands, which
+// win over aliases as well. Plugins are only registered for command lines
+// that need them, so elsewhere aliases shadowed by a plugin are not known.
+var pluginCommands = map[string]bool{}
+
+// SetPluginCommands records the names of the plugins registered as
+// commands.
+func SetPluginCommands(names []string) {
+	for _, name := range names {
+		pluginCommands[strings.ToLower(name)] = true
+	}
+}
+
+// ShadowingCommand returns the kind of command an alias or macro named name
+// loses to, "built-in" or "plugin", or "" when the alias is used.
+func ShadowingCommand(name string) string {
+	switch name = strings.ToLower(name); {
+	case builtinCommands[name]:
+		return "built-in"
+	case pluginCommands[name]:
+		return "plugin"
+	default:
+		return ""
+	}
+}
+
+// LoadAliases reads the aliases and macros of the global config at
+// globalPath and of the project config, which overrides global ones with the
+// same name. Files that cannot be read are skipped; validation reports them.
+func LoadAliases(globalPath string) map[string]Alias {
+	aliases := map[string]Alias{}
+	files := []struct{ scope, path string }{
+		{"global", globalPath},
+		{"project", ProjectConfigPath()},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		data, err := ReadConfigFile(file.path)
+		if err != nil {
+			continue
+		}
+		if section, ok := asStringMap(data["aliases"]); ok {
+			for name, command := range section {
+				if command, ok := command.(string); ok {
+					name = strings.ToLower(name)
+					aliases[name] = Alias{Name: name, Steps: []string{command}, Scope: file.scope, Source: file.path}
+				}
+			}
+		}
+		if section, ok := asStringMap(data["macros"]); ok {
+			for name, steps := range section {
+				if steps, ok := steps.([]any); ok {
+					name = strings.ToLower(name)
+					macro := Alias{Name: name, Macro: true, Scope: file.scope, Source: file.path}
+					for _, step := range steps {
+						macro.Steps = append(macro.Steps, fmt.Sprint(step))
+					}
+					aliases[name] = macro
+				}
+			}
+		}
+	}
+	return aliases
+}
+
+// SortedAliases returns aliases sorted by name.
+func SortedAliases(aliases map[string]Alias) []Alias {
+	sorted := make([]Alias, 0, len(aliases))
+	for _, a := range aliases {
+		sorted = append(sorted, a)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// aliasPlaceholder matches {{1}}, {{2}}, ... and {{@}} in alias commands.
+var aliasPlaceholder = regexp.MustCompile(`\{\{\s*(\d+|@)\s*\}\}`)
+
+// ExpandAlias turns an alias command into arguments: {{n}} is replaced by
+// the n-th argument, a word that is just {{@}} by all arguments, and
+// arguments no placeholder refers to are appended.
+func ExpandAlias(command string, args []string) ([]string, error) {
+	words, err := SplitCommandLine(command)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make([]bool, len(args))
+	var missing int
+	var expanded []string
+	for _, word := range words {
+		if aliasPlaceholder.FindString(word) == word && strings.Contains(word, "@") {
+			expanded = append(expanded, args...)
+			for i := range used {
+				used[i] = true
+			}
+			continue
+		}
+		word = aliasPlaceholder.ReplaceAllStringFunc(word, func(match string) string {
+			ref := aliasPlaceholder.FindStringSubmatch(match)[1]
+			if ref == "@" {
+				for i := range used {
+					used[i] = true
+				}
+				return strings.Join(args, " ")
+			}
+			n, _ := strconv.Atoi(ref)
+			if n < 1 || n > len(args) {
+				missing = max(missing, n)
+				return ""
+			}
+			used[n-1] = true
+			return args[n-1]
+		})
+		expanded = append(expanded, word)
+	}
+	if missing > 0 {
+		return nil, fmt.Errorf("needs at least %d argument(s), got %d", missing, len(args))
+	}
+
+	for i, arg := range args {
+		if !used[i] {
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+// AliasPrefix returns the words of an alias command before its first
+// placeholder, which is what the arguments of the alias are completed
+// against.
+func AliasPrefix(command string) []string {
+	words, _ := SplitCommandLine(command)
+	for i, word := range words {
+		if aliasPlaceholder.MatchString(word) {
+			return words[:i]
+		}
+	}
+	return words
+}
+
+// SplitCommandLine splits a command line into words like a shell does:
+// on whitespace, honouring single and double quotes and backslash escapes.
+func SplitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in '%s'", quote, line)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in '%s'", line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
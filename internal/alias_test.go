@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExpandAlias checks placeholder substitution, quoting and that unused
+// arguments are appended.
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{command: "logs tail {{1}} --since 30m", args: []string{"api"}, want: []string{"logs", "tail", "api", "--since", "30m"}},
+		{command: "logs tail {{1}}", args: []string{"api", "-f"}, want: []string{"logs", "tail", "api", "-f"}},
+		{command: "logs tail --contexts={{2}} {{1}}", args: []string{"api", "prod"}, want: []string{"logs", "tail", "--contexts=prod", "api"}},
+		{command: "cache get {{@}} -o json", args: []string{"a", "b"}, want: []string{"cache", "get", "a", "b", "-o", "json"}},
+		{command: `cache get "log groups" 'a b' c\ d`, want: []string{"cache", "get", "log groups", "a b", "c d"}},
+		{command: "logs tail {{2}}", args: []string{"api"}, wantErr: true},
+		{command: `logs tail "api`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandAlias(tt.command, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q %v: error = %v, want error %v", tt.command, tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q %v = %q, want %q", tt.command, tt.args, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,39 +13,68 @@ import (
 // Struct for unmarshalling JSON from `aws logs describe-log-groups`
 type logGroupsResponse struct {
 	LogGroups []struct {
-		LogGroupName string `json:"logGroupName"`
+		LogGroupName    string `json:"logGroupName"`
+		RetentionInDays int    `json:"retentionInDays"`
+		StoredBytes     int64  `json:"storedBytes"`
 	} `json:"logGroups"`
 }
 
+// LogGroup is a CloudWatch log group as cached by the log_groups provider.
+type LogGroup struct {
+	Name string `json:"name"`
+	// RetentionDays is 0 when events never expire
+	RetentionDays int   `json:"retention_days,omitempty"`
+	StoredBytes   int64 `json:"stored_bytes,omitempty"`
+}
+
 // AutoCompleteLogGroups dynamically fetches CloudWatch log groups for completion.
+// Suggestions come from the cache namespace of the current profile/region, so
+// log groups of one environment never leak into another's completion, and are
+// narrowed to the log group prefix of the active context.
 func AutoCompleteLogGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	target, err := CurrentTarget()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	target.NoPrompt = true
 
-	logGroup := viper.GetStringSlice("cache.log_groups")
+	// Serve cached data instantly; stale entries are refreshed in the background
+	var logGroup []LogGroup
+	if entry, ok := GetCacheEntry(target, "log_groups"); ok {
+		logGroup, _ = DecodeCacheEntry[[]LogGroup](entry)
+		if entry.Stale() {
+			RefreshCacheInBackground("log_groups")
+		}
+	}
 	if len(logGroup) <= 0 {
-		out, err := exec.Command("aws", "logs", "describe-log-groups", "--output", "json").Output()
+		groups, err := describeLogGroups(context.Background(), target)
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
+		logGroup = groups
+		SetCacheEntry(target, "log_groups", logGroup)
+	}
 
-		var resp logGroupsResponse
-		if err := json.Unmarshal(out, &resp); err != nil {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
+	var suggestions []string
+	seen := map[string]bool{}
+	toComplete = strings.ToLower(toComplete)
 
-		logGroup = make([]string, len(resp.LogGroups))
-		for i, lg := range resp.LogGroups {
-			logGroup[i] = lg.LogGroupName
+	// Log groups declared by the project config come first
+	for _, name := range viper.GetStringSlice("log_groups") {
+		if !seen[name] && strings.Contains(strings.ToLower(name), toComplete) {
+			seen[name] = true
+			suggestions = append(suggestions, name)
 		}
-		viper.Set("cache.log_groups", logGroup)
-		viper.WriteConfig()
 	}
 
-	var suggestions []string
-	toComplete = strings.ToLower(toComplete)
 	for _, lg := range logGroup {
-		name := lg
+		name := lg.Name
+		if seen[name] || !strings.HasPrefix(name, target.LogGroupPrefix) {
+			continue
+		}
 		// case-insensitive substring match
 		if strings.Contains(strings.ToLower(name), toComplete) {
+			seen[name] = true
 			suggestions = append(suggestions, name)
 		}
 	}
@@ -53,24 +82,75 @@ func AutoCompleteLogGroups(cmd *cobra.Command, args []string, toComplete string)
 	return suggestions, cobra.ShellCompDirectiveNoFileComp
 }
 
-func CacheLogGroups() error {
-	out, err := exec.Command("aws", "logs", "describe-log-groups", "--output", "json").Output()
+func init() {
+	RegisterCacheProvider(CacheProvider{
+		Name:        "log_groups",
+		Description: "CloudWatch log groups with retention and size, used for completion",
+		TTL:         DefaultCacheTTL,
+		// v1 cached bare names; v2 caches LogGroup objects
+		Schema: 2,
+		Migrations: map[int]CacheMigration{
+			1: func(value any) (any, error) {
+				names, ok := value.([]any)
+				if !ok {
+					return nil, fmt.Errorf("expected a list, got %s", typeName(value))
+				}
+				groups := make([]LogGroup, 0, len(names))
+				for _, name := range names {
+					s, ok := name.(string)
+					if !ok {
+						return nil, fmt.Errorf("expected a log group name, got %s", typeName(name))
+					}
+					groups = append(groups, LogGroup{Name: s})
+				}
+				return groups, nil
+			},
+		},
+		Fetch: func(ctx context.Context, target Target) (any, error) {
+			return describeLogGroups(ctx, target)
+		},
+	})
+}
+
+// describeLogGroups lists all log groups visible to target.
+func describeLogGroups(ctx context.Context, target Target) ([]LogGroup, error) {
+	awsCmd, err := target.CommandContext(ctx, "logs", "describe-log-groups", "--output", "json")
 	if err != nil {
-		return fmt.Errorf("error describing log groups: %w", err)
+		return nil, err
+	}
+	out, err := awsCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error describing log groups: %w", err)
 	}
 
 	var resp logGroupsResponse
 	if err := json.Unmarshal(out, &resp); err != nil {
-		return fmt.Errorf("error unmarshalling log groups: %w", err)
+		return nil, fmt.Errorf("error unmarshalling log groups: %w", err)
 	}
 
-	logGroups := make([]string, len(resp.LogGroups))
+	logGroups := make([]LogGroup, len(resp.LogGroups))
 	for i, lg := range resp.LogGroups {
-		logGroups[i] = lg.LogGroupName
+		logGroups[i] = LogGroup{
+			Name:          lg.LogGroupName,
+			RetentionDays: lg.RetentionInDays,
+			StoredBytes:   lg.StoredBytes,
+		}
+	}
+	return logGroups, nil
+}
+
+// AutoCompleteContexts completes the names of configured contexts.
+func AutoCompleteContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	contexts, err := Contexts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
-	viper.Set("cache.log_groups", logGroups)
-	if err := viper.WriteConfig(); err != nil {
-		return fmt.Errorf("failed to persist log groups cache: %w", err)
+
+	var suggestions []string
+	for _, ctx := range contexts {
+		if strings.HasPrefix(ctx.Name, strings.ToLower(toComplete)) {
+			suggestions = append(suggestions, ctx.Name)
+		}
 	}
-	return nil
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
 }
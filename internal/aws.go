@@ -1,28 +1,223 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
-func GetLogs(logGroup string, follow bool, since time.Duration) error {
-	// Build base command
-	cmdArgs := []string{"logs", "tail", logGroup}
+// Target identifies the AWS profile and region an AWS CLI call runs against,
+// together with the settings of the context it was resolved from.
+// Empty Profile/Region fall back to whatever the AWS CLI resolves on its own
+// (AWS_PROFILE, AWS_REGION, ~/.aws/config).
+type Target struct {
+	Context        string
+	Profile        string
+	Region         string
+	RoleARN        string
+	ExternalID     string
+	SessionName    string
+	MFASerial      string
+	SSOStartURL    string
+	SSORegion      string
+	SSOAccountID   string
+	SSORoleName    string
+	LogGroupPrefix string
+	Source         string
 
-	// Add flags dynamically
-	if follow {
-		cmdArgs = append(cmdArgs, "--follow")
+	// NoPrompt disables interactive MFA prompts, e.g. during shell completion
+	NoPrompt bool
+}
+
+// CurrentTarget resolves the target for the current command from the global
+// flags, the active context and the config defaults.
+func CurrentTarget() (Target, error) {
+	return ResolveTarget(CurrentContextName())
+}
+
+// ResolveTarget builds the target for the named context (or the config
+// defaults when name is empty), applying the --profile/--region overrides.
+func ResolveTarget(name string) (Target, error) {
+	t := Target{
+		Profile: viper.GetString("aws.profile"),
+		Region:  viper.GetString("aws.region"),
+		Source:  DefaultSource,
 	}
-	if since > 0 {
-		// Format duration for AWS CLI (e.g. "10m", "1h")
-		cmdArgs = append(cmdArgs, "--since", formatSinceForAWS(since))
+
+	if name != "" {
+		ctx, err := GetContext(name)
+		if err != nil {
+			return Target{}, fmt.Errorf("%w (use 'pcli context list' to see available contexts)", err)
+		}
+		t = t.withContext(ctx)
+	}
+
+	if Overrides.Profile != "" {
+		t.Profile = Overrides.Profile
+	}
+	if Overrides.Region != "" {
+		t.Region = Overrides.Region
+	}
+	return t, nil
+}
+
+// withContext returns the target with the settings of ctx applied over it.
+func (t Target) withContext(ctx Context) Target {
+	t.Context = ctx.Name
+	if ctx.Profile != "" {
+		t.Profile = ctx.Profile
+	}
+	if ctx.Region != "" {
+		t.Region = ctx.Region
+	}
+	if ctx.Source != "" {
+		t.Source = ctx.Source
+	}
+	t.RoleARN = ctx.RoleARN
+	t.ExternalID = ctx.ExternalID
+	t.SessionName = ctx.SessionName
+	t.MFASerial = ctx.MFASerial
+	t.SSOStartURL = ctx.SSOStartURL
+	t.SSORegion = ctx.SSORegion
+	t.SSOAccountID = ctx.SSOAccountID
+	t.SSORoleName = ctx.SSORoleName
+	t.LogGroupPrefix = ctx.LogGroupPrefix
+	return t
+}
+
+// Args appends the --profile/--region flags for this target to an AWS CLI
+// argument list.
+func (t Target) Args(args ...string) []string {
+	if t.Profile != "" {
+		args = append(args, "--profile", t.Profile)
+	}
+	if t.Region != "" {
+		args = append(args, "--region", t.Region)
+	}
+	return args
+}
+
+// Command builds an `aws` invocation scoped to this target. When the target
+// runs with temporary credentials (SSO or an assumed role), they are handed
+// to the AWS CLI through the environment instead of --profile, which would
+// take precedence over them.
+func (t Target) Command(args ...string) (*exec.Cmd, error) {
+	return t.CommandContext(context.Background(), args...)
+}
+
+// CommandContext is like Command, but the AWS CLI is killed when ctx is done.
+func (t Target) CommandContext(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	creds, ok, err := t.credentials()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return exec.CommandContext(ctx, "aws", t.Args(args...)...), nil
+	}
+
+	withCreds := t
+	withCreds.Profile = ""
+	awsCmd := exec.CommandContext(ctx, "aws", withCreds.Args(args...)...)
+	awsCmd.Env = append(os.Environ(), creds.Env()...)
+	return awsCmd, nil
+}
+
+// EnsureCredentials resolves the target's temporary credentials up front,
+// e.g. so an MFA prompt happens before any progress output starts.
+func (t Target) EnsureCredentials() error {
+	_, _, err := t.credentials()
+	return err
+}
+
+// credentials returns the temporary credentials the target runs with, if
+// any. Assumed roles take their base credentials from SSO when configured.
+func (t Target) credentials() (Credentials, bool, error) {
+	switch {
+	case t.RoleARN != "":
+		creds, err := AssumeRole(t)
+		return creds, true, err
+	case t.UsesSSO():
+		creds, err := SSOCredentials(t)
+		return creds, true, err
+	default:
+		return Credentials{}, false, nil
+	}
+}
+
+// Scope returns the cache namespace for this target, e.g. "prod@eu-west-1".
+// Unset fields are reported as "default" so that the AWS CLI's own defaults
+// still get a stable namespace of their own. Targets running as an assumed
+// or SSO role reach another account than their profile, so the account and
+// role are appended: "prod@eu-west-1#123456789012/admin".
+func (t Target) Scope() string {
+	profile, region := t.Profile, t.Region
+	if profile == "" {
+		profile = "default"
+	}
+	if region == "" {
+		region = "default"
+	}
+	// Keep scopes compatible with the dot-free, lowercase keys older
+	// versions stored in the viper config
+	scope := strings.ToLower(profile + "@" + region)
+	if identity := t.identity(); identity != "" {
+		scope += "#" + strings.ToLower(identity)
+	}
+	return strings.ReplaceAll(scope, ".", "_")
+}
+
+// identity returns the account and role the target's temporary credentials
+// act as, like "123456789012/admin", or "" when it uses its profile as is.
+// The role wins over SSO, whose credentials only serve to assume it.
+func (t Target) identity() string {
+	switch {
+	case t.RoleARN != "":
+		// arn:aws:iam::123456789012:role/path/Admin
+		parts := strings.SplitN(t.RoleARN, ":", 6)
+		if len(parts) < 6 {
+			return t.RoleARN
+		}
+		return parts[4] + "/" + strings.TrimPrefix(parts[5], "role/")
+	case t.UsesSSO():
+		return t.SSOAccountID + "/" + t.SSORoleName
+	default:
+		return ""
+	}
+}
+
+// String describes the target for user-facing messages.
+func (t Target) String() string {
+	var parts []string
+	if t.Context != "" {
+		parts = append(parts, "context "+t.Context)
+	}
+	if t.Profile != "" {
+		parts = append(parts, "profile "+t.Profile)
+	}
+	if t.Region != "" {
+		parts = append(parts, "region "+t.Region)
+	}
+	if len(parts) == 0 {
+		return "default AWS credentials"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func GetLogs(target Target, logGroup string, follow bool, since time.Duration) error {
+	if err := ValidateSource(target.Source); err != nil {
+		return err
 	}
 
 	// Create the command
-	awsCmd := exec.Command("aws", cmdArgs...)
+	awsCmd, err := target.Command(tailArgs(logGroup, follow, since)...)
+	if err != nil {
+		return err
+	}
 
 	// Stream output directly to terminal
 	awsCmd.Stdout = os.Stdout
@@ -30,7 +225,7 @@ func GetLogs(logGroup string, follow bool, since time.Duration) error {
 	awsCmd.Stdin = os.Stdin // allows Ctrl+C interrupt to propagate
 
 	// Print what we're running (for debug)
-	fmt.Printf("Running: aws %s\n\n", strings.Join(cmdArgs, " "))
+	fmt.Printf("Running: aws %s\n\n", strings.Join(awsCmd.Args[1:], " "))
 
 	// Execute
 	if err := awsCmd.Run(); err != nil {
@@ -40,6 +235,22 @@ func GetLogs(logGroup string, follow bool, since time.Duration) error {
 	return nil
 }
 
+// tailArgs builds the `aws logs tail` argument list.
+func tailArgs(logGroup string, follow bool, since time.Duration) []string {
+	// Build base command
+	cmdArgs := []string{"logs", "tail", logGroup}
+
+	// Add flags dynamically
+	if follow {
+		cmdArgs = append(cmdArgs, "--follow")
+	}
+	if since > 0 {
+		// Format duration for AWS CLI (e.g. "10m", "1h")
+		cmdArgs = append(cmdArgs, "--since", formatSinceForAWS(since))
+	}
+	return cmdArgs
+}
+
 // formatSinceForAWS converts a duration into the compact format expected by
 // `aws logs tail --since`, e.g. 90m, 2h, 1d. It rounds toward the largest
 // sensible unit and avoids verbose strings like "1h0m0s".
@@ -2,78 +2,444 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v2"
 )
 
-// DeleteConfigKey removes a top-level key (like "cache") from the file
-// that Viper is currently using, and persists the change.
-// Works for YAML (.yml/.yaml) and JSON (.json).
-func DeleteConfigKey(key string) error {
-	// 1. Find which file viper is actually using
-	cfgPath := viper.ConfigFileUsed()
-	if cfgPath == "" {
-		return fmt.Errorf("no config file bound to viper (ConfigFileUsed() is empty)")
+// DefaultCacheTTL is how long cache entries stay fresh unless cache_ttl.<key>
+// configures otherwise.
+const DefaultCacheTTL = 24 * time.Hour
+
+// CacheEntry is a cached value together with when it was fetched and how
+// long it stays fresh.
+type CacheEntry struct {
+	Value     any
+	FetchedAt time.Time
+	TTL       time.Duration
+	// Schema is the version of the shape of Value, see CacheProvider.Schema
+	Schema int
+}
+
+// Age returns how long ago the entry was fetched.
+func (e CacheEntry) Age() time.Duration {
+	return time.Since(e.FetchedAt)
+}
+
+// ExpiresAt returns when the entry becomes stale.
+func (e CacheEntry) ExpiresAt() time.Time {
+	return e.FetchedAt.Add(e.TTL)
+}
+
+// CacheMaxAge overrides the TTL of every cache entry when deciding whether
+// it is stale; it is set by the global --max-age flag.
+var CacheMaxAge time.Duration
+
+// Stale reports whether the entry outlived its TTL, or CacheMaxAge when one
+// is given.
+func (e CacheEntry) Stale() bool {
+	maxAge := e.TTL
+	if CacheMaxAge > 0 {
+		maxAge = CacheMaxAge
+	}
+	return e.Age() > maxAge
+}
+
+// cacheRecord is the on-disk form of a CacheEntry. Records written before
+// schemas were introduced have no schema and count as version 1.
+type cacheRecord struct {
+	Value     any       `json:"value"`
+	FetchedAt time.Time `json:"fetched_at"`
+	TTL       string    `json:"ttl"`
+	Schema    int       `json:"schema,omitempty"`
+	// Context is the context the value was fetched for, if any
+	Context string `json:"context,omitempty"`
+}
+
+// cacheData is the content of the cache file: records keyed by target scope
+// and then by cache key.
+type cacheData map[string]map[string]cacheRecord
+
+// CacheDir returns the directory pcli keeps cached data in, following the
+// XDG base directory spec ($XDG_CACHE_HOME/pcli, default ~/.cache/pcli).
+func CacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "pcli"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
 	}
+	return filepath.Join(home, ".cache", "pcli"), nil
+}
 
-	// 2. Read raw file bytes
-	raw, err := os.ReadFile(cfgPath)
+// CachePath returns the file the cache is stored in,
+// $XDG_CACHE_HOME/pcli/cache.json by default.
+func CachePath() (string, error) {
+	dir, err := CacheDir()
 	if err != nil {
-		return fmt.Errorf("read config: %w", err)
+		return "", err
 	}
+	return filepath.Join(dir, "cache.json"), nil
+}
 
-	// 3. Figure out format from extension
-	ext := strings.ToLower(filepath.Ext(cfgPath))
+// CacheTTL returns the configured TTL of a cache key (cache_ttl.<key>),
+// falling back to the TTL of its provider and then DefaultCacheTTL.
+func CacheTTL(key string) time.Duration {
+	if ttl := getDuration("cache_ttl." + key); ttl > 0 {
+		return ttl
+	}
+	if p, ok := cacheProviders[key]; ok && p.TTL > 0 {
+		return p.TTL
+	}
+	return DefaultCacheTTL
+}
 
-	// We'll load into a generic map
-	data := map[string]any{}
+// GetCacheEntry returns the cache entry of key in the namespace of target.
+func GetCacheEntry(target Target, key string) (CacheEntry, bool) {
+	data, err := loadCache()
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	record, ok := data[target.Scope()][key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	return record.entry(key), true
+}
 
-	switch ext {
-	case ".yml", ".yaml":
-		if err := yaml.Unmarshal(raw, &data); err != nil {
-			return fmt.Errorf("unmarshal yaml: %w", err)
+// CacheEntries returns all cache entries in the namespace of target.
+func CacheEntries(target Target) (map[string]CacheEntry, error) {
+	data, err := loadCache()
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]CacheEntry{}
+	for key, record := range data[target.Scope()] {
+		entries[key] = record.entry(key)
+	}
+	return entries, nil
+}
+
+// SetCacheEntry stores value under key in the namespace of target, stamped
+// with the current time, the key's TTL, its schema version and the context
+// of target.
+func SetCacheEntry(target Target, key string, value any) error {
+	return updateCache(func(data cacheData) {
+		scope := target.Scope()
+		if data[scope] == nil {
+			data[scope] = map[string]cacheRecord{}
 		}
-	case ".json":
-		if err := json.Unmarshal(raw, &data); err != nil {
-			return fmt.Errorf("unmarshal json: %w", err)
+		data[scope][key] = cacheRecord{
+			Value:     value,
+			FetchedAt: time.Now().UTC(),
+			TTL:       CacheTTL(key).String(),
+			Schema:    CacheSchema(key),
+			Context:   target.Context,
 		}
-	default:
-		return fmt.Errorf("unsupported config format: %s", ext)
+	})
+}
+
+// ClearCache removes all cached data of every target.
+func ClearCache() error {
+	path, err := CachePath()
+	if err != nil {
+		return err
 	}
 
-	// 4. Delete the key from the top level
-	delete(data, key)
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove cache: %w", err)
+	}
+	return nil
+}
+
+// RemovedEntry identifies a cache entry dropped by DeleteCacheEntries or
+// PruneCache.
+type RemovedEntry struct {
+	Scope string
+	Key   string
+	Age   time.Duration
+}
+
+// DeleteCacheEntries removes keys from the cache namespace scope, or from
+// every namespace when scope is empty, and returns what was removed.
+func DeleteCacheEntries(scope string, keys []string) ([]RemovedEntry, error) {
+	return removeCacheEntries(scope, func(key string, _ CacheEntry) bool {
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// PruneCache removes entries older than olderThan, or stale entries when
+// olderThan is zero, from the cache namespace scope (every namespace when
+// scope is empty). Stale means the same as in 'cache list', so --max-age
+// applies.
+func PruneCache(scope string, olderThan time.Duration) ([]RemovedEntry, error) {
+	return removeCacheEntries(scope, func(_ string, entry CacheEntry) bool {
+		if olderThan > 0 {
+			return entry.Age() > olderThan
+		}
+		return entry.Stale()
+	})
+}
+
+// ClearCacheScope removes all entries of one cache namespace.
+func ClearCacheScope(scope string) ([]RemovedEntry, error) {
+	return removeCacheEntries(scope, func(string, CacheEntry) bool { return true })
+}
+
+// removeCacheEntries drops every entry in scope (or all scopes when empty)
+// for which remove returns true. Namespaces left empty are dropped too.
+func removeCacheEntries(scope string, remove func(key string, entry CacheEntry) bool) ([]RemovedEntry, error) {
+	var removed []RemovedEntry
+	err := updateCache(func(data cacheData) {
+		for s, records := range data {
+			if scope != "" && s != scope {
+				continue
+			}
+			for key, record := range records {
+				entry := record.entry(key)
+				if remove(key, entry) {
+					delete(records, key)
+					removed = append(removed, RemovedEntry{Scope: s, Key: key, Age: entry.Age()})
+				}
+			}
+			if len(records) == 0 {
+				delete(data, s)
+			}
+		}
+	})
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].Scope != removed[j].Scope {
+			return removed[i].Scope < removed[j].Scope
+		}
+		return removed[i].Key < removed[j].Key
+	})
+	return removed, err
+}
+
+// importLegacyCache writes cache data that older versions of pcli stored in
+// the "cache" key of the config file into the cache file. Entries already in
+// the cache file are newer and are kept.
+func importLegacyCache(legacy map[string]any) error {
+	return updateCache(func(data cacheData) {
+		for name, raw := range legacy {
+			// Before namespacing, entries lived directly under "cache" and
+			// belonged to the default target
+			scope, key := name, ""
+			if !strings.Contains(name, "@") {
+				scope, key = Target{}.Scope(), name
+			}
 
-	// 5. Marshal back to original format
-	var updated []byte
-	switch ext {
-	case ".yml", ".yaml":
-		updated, err = yaml.Marshal(data)
-		if err != nil {
-			return fmt.Errorf("marshal yaml: %w", err)
+			entries := map[string]any{key: raw}
+			if key == "" {
+				entries, _ = asStringMap(raw)
+			}
+			if data[scope] == nil {
+				data[scope] = map[string]cacheRecord{}
+			}
+			for key, raw := range entries {
+				// Never overwrite newer data already in the cache file
+				if _, exists := data[scope][key]; !exists {
+					data[scope][key] = legacyRecord(key, raw)
+				}
+			}
 		}
-	case ".json":
-		updated, err = json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			return fmt.Errorf("marshal json: %w", err)
+	})
+}
+
+// moveRoleScopes moves records that older versions of pcli filed under the
+// profile@region scope of a context running as an assumed or SSO role into
+// the scope of that role. scopes maps those contexts to their new scope.
+// Records without a context in a scope shared with such contexts cannot be
+// told apart and are dropped, so they are fetched again instead of showing
+// data of the wrong account. It returns how many records were moved and
+// dropped.
+func moveRoleScopes(scopes map[string]string) (moved, dropped int, err error) {
+	if len(scopes) == 0 {
+		return 0, 0, nil
+	}
+	legacyScopes := map[string]bool{}
+	for _, scope := range scopes {
+		legacy, _, _ := strings.Cut(scope, "#")
+		legacyScopes[legacy] = true
+	}
+
+	err = updateCache(func(data cacheData) {
+		for legacy := range legacyScopes {
+			records := data[legacy]
+			for key, record := range records {
+				scope, ok := scopes[record.Context]
+				if !ok && record.Context != "" {
+					// Fetched for a context using the profile itself
+					continue
+				}
+				delete(records, key)
+				if !ok {
+					dropped++
+					continue
+				}
+				moved++
+				if data[scope] == nil {
+					data[scope] = map[string]cacheRecord{}
+				}
+				// Never overwrite newer data already in the role's scope
+				if existing, exists := data[scope][key]; !exists || record.FetchedAt.After(existing.FetchedAt) {
+					data[scope][key] = record
+				}
+			}
+			if len(records) == 0 {
+				delete(data, legacy)
+			}
 		}
-		updated = append(updated, '\n') // pretty end newline
+	})
+	return moved, dropped, err
+}
+
+// legacyRecord converts a cache value from the config file, either a bare
+// value or a {value, fetched_at, ttl} map, into a record.
+func legacyRecord(key string, raw any) cacheRecord {
+	record := cacheRecord{Value: raw, TTL: CacheTTL(key).String()}
+
+	fields, ok := asStringMap(raw)
+	if !ok {
+		return record
+	}
+	fetchedAt, ok := fields["fetched_at"].(string)
+	if !ok {
+		return record
+	}
+
+	record.Value = fields["value"]
+	record.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+	if ttl, ok := fields["ttl"].(string); ok {
+		record.TTL = ttl
 	}
+	return record
+}
 
-	// 6. Write back to same file
-	if err := os.WriteFile(cfgPath, updated, 0o644); err != nil {
-		return fmt.Errorf("write config: %w", err)
+// entry converts a record into a CacheEntry. Records without a readable TTL
+// fall back to the configured TTL of key.
+func (r cacheRecord) entry(key string) CacheEntry {
+	ttl, err := time.ParseDuration(r.TTL)
+	if err != nil {
+		ttl = CacheTTL(key)
 	}
+	return CacheEntry{Value: r.Value, FetchedAt: r.FetchedAt, TTL: ttl, Schema: r.Schema}
+}
 
-	// 7. Reload into viper so in-memory matches disk
-	if err := viper.ReadInConfig(); err != nil {
-		return fmt.Errorf("reload viper: %w", err)
+// loadCache reads the cache file and upgrades its records to the current
+// schema of their providers. A missing file is an empty cache.
+func loadCache() (cacheData, error) {
+	path, err := CachePath()
+	if err != nil {
+		return nil, err
 	}
 
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cacheData{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache: %w", err)
+	}
+
+	data := cacheData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal cache: %w", err)
+	}
+	migrateCache(data)
+	return data, nil
+}
+
+// updateCache loads the cache file, applies mutate and writes it back. The
+// whole read-modify-write runs under a file lock and the file is replaced
+// atomically, so concurrent writers (e.g. completion and `cache refresh`)
+// neither lose updates nor leave a truncated file behind.
+func updateCache(mutate func(data cacheData)) error {
+	path, err := CachePath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := loadCache()
+	if err != nil {
+		return err
+	}
+
+	mutate(data)
+
+	updated, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cache: %w", err)
+	}
+	updated = append(updated, '\n')
+	if err := writeFileAtomic(path, updated, 0o600); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
 	return nil
 }
+
+// RefreshCacheInBackground starts a detached `pcli cache refresh` of the
+// given providers for the current target, so callers like shell completion
+// can serve stale data immediately. Output is discarded and the process is
+// not waited for.
+func RefreshCacheInBackground(providers ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"cache", "refresh", "--quiet"}, providers...)
+	args = append(args, overrideArgs()...)
+
+	// Leaving stdio unset detaches the child from the completion pipe, so the
+	// shell does not wait for it
+	refresh := exec.Command(exe, args...)
+	if err := refresh.Start(); err != nil {
+		return err
+	}
+	return refresh.Process.Release()
+}
+
+// overrideArgs returns the global flags that make a child pcli process see
+// the same config file and target as this one.
+func overrideArgs() []string {
+	var args []string
+	if file := viper.ConfigFileUsed(); file != "" {
+		args = append(args, "--config", file)
+	}
+	if Overrides.Context != "" {
+		args = append(args, "--context", Overrides.Context)
+	}
+	if Overrides.Profile != "" {
+		args = append(args, "--profile", Overrides.Profile)
+	}
+	if Overrides.Region != "" {
+		args = append(args, "--region", Overrides.Region)
+	}
+	return args
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheInfo describes one cache entry for `pcli cache list`.
+type CacheInfo struct {
+	Key string `json:"key"`
+	// Provider is the name of the registered provider that refreshes the
+	// entry, empty when none does
+	Provider string `json:"provider,omitempty"`
+	// Context is the context the entry was fetched for, if any
+	Context string `json:"context,omitempty"`
+	Schema  int    `json:"schema"`
+	// Items is the number of elements, or -1 when the value is not a list
+	// or object
+	Items int `json:"items"`
+	// Bytes is the size of the entry in the cache file
+	Bytes     int       `json:"bytes"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Age       string    `json:"age"`
+	TTL       string    `json:"ttl"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Stale     bool      `json:"stale"`
+}
+
+// CacheSortFields lists the fields `pcli cache list --sort` accepts.
+var CacheSortFields = []string{"key", "provider", "age", "expires", "items", "size"}
+
+// ListCache describes all cache entries in the namespace of target, sorted
+// by key.
+func ListCache(target Target) ([]CacheInfo, error) {
+	data, err := loadCache()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []CacheInfo
+	for key, record := range data[target.Scope()] {
+		entry := record.entry(key)
+		info := CacheInfo{
+			Key:       key,
+			Context:   record.Context,
+			Schema:    entry.Schema,
+			Items:     ItemCount(entry.Value),
+			FetchedAt: entry.FetchedAt,
+			Age:       entry.Age().Round(time.Second).String(),
+			TTL:       entry.TTL.String(),
+			ExpiresAt: entry.ExpiresAt(),
+			Stale:     entry.Stale(),
+		}
+		if _, ok := cacheProviders[key]; ok {
+			info.Provider = key
+		}
+		// Records sit two levels deep in the indented cache file
+		if raw, err := json.MarshalIndent(record, "    ", "  "); err == nil {
+			info.Bytes = len(raw)
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// SortCacheInfo sorts infos by one of CacheSortFields. Ties keep their
+// order, so the result is deterministic for key-sorted input. Sizes, item
+// counts and ages sort largest first.
+func SortCacheInfo(infos []CacheInfo, field string) error {
+	var less func(a, b CacheInfo) bool
+	switch strings.ToLower(field) {
+	case "key", "":
+		less = func(a, b CacheInfo) bool { return a.Key < b.Key }
+	case "provider":
+		less = func(a, b CacheInfo) bool { return a.Provider < b.Provider }
+	case "age":
+		less = func(a, b CacheInfo) bool { return a.FetchedAt.Before(b.FetchedAt) }
+	case "expires":
+		less = func(a, b CacheInfo) bool { return a.ExpiresAt.Before(b.ExpiresAt) }
+	case "items":
+		less = func(a, b CacheInfo) bool { return a.Items > b.Items }
+	case "size":
+		less = func(a, b CacheInfo) bool { return a.Bytes > b.Bytes }
+	default:
+		return fmt.Errorf("unknown sort field '%s' (supported: %s)", field, strings.Join(CacheSortFields, ", "))
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return less(infos[i], infos[j]) })
+	return nil
+}
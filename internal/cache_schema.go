@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CacheMigration upgrades cached data of one schema version to the next.
+// It receives the data as decoded from JSON and must return data that
+// encodes to JSON.
+type CacheMigration func(value any) (any, error)
+
+// CacheSchema returns the schema version data cached under key is written
+// with: the Schema of its provider, or 1 for keys no provider owns.
+func CacheSchema(key string) int {
+	if p, ok := cacheProviders[key]; ok && p.Schema > 0 {
+		return p.Schema
+	}
+	return 1
+}
+
+// migrateCache upgrades every record of data to the current schema of its
+// provider. Records that cannot be upgraded are dropped, so that they are
+// fetched again instead of breaking the code that reads them.
+func migrateCache(data cacheData) {
+	for scope, records := range data {
+		for key, record := range records {
+			migrated, err := migrateRecord(key, record)
+			if err != nil {
+				delete(records, key)
+				continue
+			}
+			records[key] = migrated
+		}
+		if len(records) == 0 {
+			delete(data, scope)
+		}
+	}
+}
+
+// migrateRecord upgrades record to the current schema of key by applying the
+// migrations of its provider one version at a time. Records written before
+// cache schemas existed count as version 1. It fails for records of a newer
+// schema (written by a newer pcli) and when a migration is missing or fails.
+func migrateRecord(key string, record cacheRecord) (cacheRecord, error) {
+	if record.Schema == 0 {
+		record.Schema = 1
+	}
+	current := CacheSchema(key)
+	if record.Schema == current {
+		return record, nil
+	}
+	if record.Schema > current {
+		return cacheRecord{}, fmt.Errorf("cache entry '%s' has schema v%d, newer than supported v%d", key, record.Schema, current)
+	}
+
+	provider := cacheProviders[key]
+	value := record.Value
+	for version := record.Schema; version < current; version++ {
+		migrate, ok := provider.Migrations[version]
+		if !ok {
+			return cacheRecord{}, fmt.Errorf("cache entry '%s' cannot be upgraded from schema v%d", key, version)
+		}
+		var err error
+		if value, err = migrate(value); err != nil {
+			return cacheRecord{}, fmt.Errorf("upgrade cache entry '%s' from schema v%d: %w", key, version, err)
+		}
+	}
+
+	// Readers expect data as decoded from JSON, whatever types the
+	// migrations produced
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return cacheRecord{}, fmt.Errorf("upgrade cache entry '%s': %w", key, err)
+	}
+	record.Value = nil
+	if err := json.Unmarshal(raw, &record.Value); err != nil {
+		return cacheRecord{}, fmt.Errorf("upgrade cache entry '%s': %w", key, err)
+	}
+	record.Schema = current
+	return record, nil
+}
+
+// DecodeCacheEntry converts the value of a cache entry into the type its
+// provider stores, e.g. []LogGroup for log_groups.
+func DecodeCacheEntry[T any](entry CacheEntry) (T, error) {
+	var value T
+	raw, err := json.Marshal(entry.Value)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, fmt.Errorf("unexpected cache data: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,428 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TestSetCacheEntryConcurrentWriters hammers the cache file with concurrent
+// read-modify-write updates and checks that none of them is lost and the
+// file is never left truncated.
+func TestSetCacheEntryConcurrentWriters(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	const writers = 20
+	const writesPerWriter = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*writesPerWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			target := Target{Profile: fmt.Sprintf("writer%d", w)}
+			for i := 0; i < writesPerWriter; i++ {
+				if err := SetCacheEntry(target, fmt.Sprintf("key%d", i), []string{"a", "b"}); err != nil {
+					errs <- err
+				}
+				// Readers must always see a complete file
+				if _, err := loadCache(); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent cache update failed: %v", err)
+	}
+
+	path, err := CachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := cacheData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("cache file is corrupt: %v", err)
+	}
+
+	for w := 0; w < writers; w++ {
+		scope := Target{Profile: fmt.Sprintf("writer%d", w)}.Scope()
+		if got := len(data[scope]); got != writesPerWriter {
+			t.Errorf("scope %s has %d entries, want %d", scope, got, writesPerWriter)
+		}
+	}
+}
+
+// TestWriteFileAtomicConcurrent checks that concurrent atomic writes always
+// leave one complete version of the file behind.
+func TestWriteFileAtomicConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 50; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload, _ := json.Marshal(map[string]int{"writer": w})
+			if err := writeFileAtomic(path, payload, 0o644); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]int
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("file is corrupt after concurrent writes: %q", raw)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+// TestMigrateRecord checks that log groups cached before schemas existed are
+// upgraded and that data of a newer schema is rejected.
+func TestMigrateRecord(t *testing.T) {
+	old := cacheRecord{Value: []any{"/aws/lambda/api", "/ecs/web"}}
+	migrated, err := migrateRecord("log_groups", old)
+	if err != nil {
+		t.Fatalf("migrate v1 log_groups: %v", err)
+	}
+	if migrated.Schema != CacheSchema("log_groups") {
+		t.Errorf("schema = %d, want %d", migrated.Schema, CacheSchema("log_groups"))
+	}
+	groups, err := DecodeCacheEntry[[]LogGroup](migrated.entry("log_groups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[1].Name != "/ecs/web" {
+		t.Errorf("migrated log groups = %+v", groups)
+	}
+
+	if _, err := migrateRecord("log_groups", cacheRecord{Value: []any{}, Schema: 99}); err == nil {
+		t.Error("expected data of a newer schema to be rejected")
+	}
+	if _, err := migrateRecord("log_groups", cacheRecord{Value: "not a list"}); err == nil {
+		t.Error("expected malformed v1 data to be rejected")
+	}
+}
+
+// TestTargetScope checks that targets reaching different accounts through
+// the same profile and region get cache namespaces of their own.
+func TestTargetScope(t *testing.T) {
+	base := Target{Profile: "shared", Region: "eu-west-1"}
+	admin, reader, sso := base, base, base
+	admin.RoleARN = "arn:aws:iam::111111111111:role/Admin"
+	reader.RoleARN = "arn:aws:iam::222222222222:role/Admin"
+	sso.SSOAccountID, sso.SSORoleName = "333333333333", "ReadOnly"
+
+	scopes := map[string]string{
+		base.Scope():   "base",
+		admin.Scope():  "admin",
+		reader.Scope(): "reader",
+		sso.Scope():    "sso",
+	}
+	if len(scopes) != 4 {
+		t.Fatalf("scopes collide: %v", scopes)
+	}
+	if got, want := admin.Scope(), "shared@eu-west-1#111111111111/admin"; got != want {
+		t.Errorf("admin scope = %q, want %q", got, want)
+	}
+	if got, want := base.Scope(), "shared@eu-west-1"; got != want {
+		t.Errorf("base scope = %q, want %q", got, want)
+	}
+}
+
+// TestMigrateRoleScopes checks that cache entries older versions filed under
+// the profile of a role context move to the role's namespace, and that
+// entries of unknown origin in that namespace are dropped.
+func TestMigrateRoleScopes(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	plain := Target{Context: "dev", Profile: "shared", Region: "eu-west-1"}
+	role := plain
+	role.Context, role.RoleARN = "prod", "arn:aws:iam::111111111111:role/Admin"
+	legacy := plain.Scope()
+
+	err := updateCache(func(data cacheData) {
+		data[legacy] = map[string]cacheRecord{
+			"log_groups": {Value: []any{"/prod"}, TTL: "1h", Context: "prod"},
+			"buckets":    {Value: []any{"dev"}, TTL: "1h", Context: "dev"},
+			"queues":     {Value: []any{"?"}, TTL: "1h"},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, err := migrateRoleScopes(map[string]any{
+		"aws": map[string]any{"profile": "shared", "region": "eu-west-1"},
+		"contexts": map[string]any{
+			"dev":  map[string]any{},
+			"prod": map[string]any{"role_arn": role.RoleARN},
+		},
+	})
+	if err != nil || step.apply == nil {
+		t.Fatalf("step = %+v, %v, want cached data to move", step, err)
+	}
+	changes, err := step.apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("changes = %v, want one move and one drop", changes)
+	}
+
+	data, err := loadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data[role.Scope()]["log_groups"]; !ok {
+		t.Errorf("log_groups of prod not moved to %s: %v", role.Scope(), data)
+	}
+	if _, ok := data[legacy]["buckets"]; !ok || len(data[legacy]) != 1 {
+		t.Errorf("%s = %v, want only the buckets of dev", legacy, data[legacy])
+	}
+}
+
+// TestSeedValidate checks the envelope and entry checks of seed files.
+func TestSeedValidate(t *testing.T) {
+	entries := map[string]cacheRecord{"buckets": {Value: []any{"a"}, TTL: "1h"}}
+	tests := []struct {
+		name    string
+		seed    Seed
+		wantErr string
+	}{
+		{"valid", Seed{SchemaVersion: SeedSchemaVersion, Entries: entries}, ""},
+		{"missing schema", Seed{Entries: entries}, "missing schema_version"},
+		{"newer schema", Seed{SchemaVersion: SeedSchemaVersion + 1, Entries: entries}, "upgrade pcli"},
+		{"no entries", Seed{SchemaVersion: SeedSchemaVersion}, "no entries"},
+		{"missing value", Seed{SchemaVersion: SeedSchemaVersion, Entries: map[string]cacheRecord{"buckets": {TTL: "1h"}}}, "missing value"},
+		{"bad ttl", Seed{SchemaVersion: SeedSchemaVersion, Entries: map[string]cacheRecord{"buckets": {Value: []any{}, TTL: "soon"}}}, "bad ttl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.seed.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestImportCache checks that an import replaces the namespace, and that a
+// merge only takes missing or newer entries.
+func TestImportCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	target := Target{Profile: "dev", Region: "eu-west-1"}
+	now := time.Now().UTC()
+	seed := Seed{SchemaVersion: SeedSchemaVersion, Entries: map[string]cacheRecord{
+		"buckets": {Value: []any{"seed"}, FetchedAt: now.Add(-time.Hour), TTL: "1h"},
+		"queues":  {Value: []any{"seed"}, FetchedAt: now.Add(-time.Hour), TTL: "1h"},
+	}}
+	reset := func() {
+		t.Helper()
+		err := updateCache(func(data cacheData) {
+			data[target.Scope()] = map[string]cacheRecord{
+				"buckets": {Value: []any{"cached"}, FetchedAt: now, TTL: "1h"},
+				"topics":  {Value: []any{"cached"}, FetchedAt: now, TTL: "1h"},
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	values := func() map[string]any {
+		t.Helper()
+		data, err := loadCache()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]any{}
+		for key, record := range data[target.Scope()] {
+			values[key] = record.Value.([]any)[0]
+		}
+		return values
+	}
+
+	reset()
+	result, err := ImportCache(target, seed, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Imported) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("merge result = %+v, want queues imported and buckets skipped", result)
+	}
+	if got := fmt.Sprint(values()); got != "map[buckets:cached queues:seed topics:cached]" {
+		t.Errorf("after merge cache = %s", got)
+	}
+
+	reset()
+	if _, err := ImportCache(target, seed, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(values()); got != "map[buckets:seed queues:seed]" {
+		t.Errorf("after replace cache = %s", got)
+	}
+}
+
+// TestAutoImportSeed checks that each version of the configured seed file
+// is imported once per namespace, and a broken one is reported once.
+func TestAutoImportSeed(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "seed.json")
+	writeSeed := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seed := `{"schema_version": 1, "entries": {"buckets": {"value": ["seed"], "ttl": "1h"}}}`
+	viper.Set("cache_seed", path)
+	target := Target{Profile: "dev"}
+	modTime := time.Now().Add(-time.Hour)
+
+	writeSeed(seed, modTime)
+	if imported, err := AutoImportSeed(target); err != nil || imported != path {
+		t.Fatalf("first import = %q, %v", imported, err)
+	}
+	if _, ok := GetCacheEntry(target, "buckets"); !ok {
+		t.Fatal("seed not imported")
+	}
+
+	if err := updateCache(func(data cacheData) { delete(data, target.Scope()) }); err != nil {
+		t.Fatal(err)
+	}
+	if imported, err := AutoImportSeed(target); err != nil || imported != "" {
+		t.Errorf("same version imported again: %q, %v", imported, err)
+	}
+	if _, ok := GetCacheEntry(target, "buckets"); ok {
+		t.Error("cleared cache refilled from the seed")
+	}
+	if imported, err := AutoImportSeed(Target{Profile: "prod"}); err != nil || imported != path {
+		t.Errorf("import into another namespace = %q, %v", imported, err)
+	}
+
+	writeSeed("{", modTime.Add(time.Minute))
+	if _, err := AutoImportSeed(target); err == nil {
+		t.Error("expected the broken seed to be reported")
+	}
+	if _, err := AutoImportSeed(target); err != nil {
+		t.Errorf("broken seed reported twice: %v", err)
+	}
+
+	writeSeed(seed, modTime.Add(2*time.Minute))
+	if imported, err := AutoImportSeed(target); err != nil || imported != path {
+		t.Errorf("new version not imported: %q, %v", imported, err)
+	}
+}
+
+// TestCacheEntryStale checks that entries go stale after their TTL, or
+// after CacheMaxAge when --max-age is given.
+func TestCacheEntryStale(t *testing.T) {
+	t.Cleanup(func() { CacheMaxAge = 0 })
+
+	tests := []struct {
+		age, ttl, maxAge time.Duration
+		want             bool
+	}{
+		{age: 30 * time.Minute, ttl: time.Hour, want: false},
+		{age: 2 * time.Hour, ttl: time.Hour, want: true},
+		{age: 30 * time.Minute, ttl: time.Hour, maxAge: 10 * time.Minute, want: true},
+		{age: 2 * time.Hour, ttl: time.Hour, maxAge: 24 * time.Hour, want: false},
+	}
+	for _, tt := range tests {
+		CacheMaxAge = tt.maxAge
+		entry := CacheEntry{FetchedAt: time.Now().Add(-tt.age), TTL: tt.ttl}
+		if got := entry.Stale(); got != tt.want {
+			t.Errorf("age %s, ttl %s, max age %s: stale = %v, want %v", tt.age, tt.ttl, tt.maxAge, got, tt.want)
+		}
+	}
+}
+
+// TestPruneCache checks that prune removes the entries 'cache list' marks
+// stale, honouring --max-age, or those older than --older-than.
+func TestPruneCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() { CacheMaxAge = 0 })
+
+	now := time.Now().UTC()
+	fill := func() {
+		t.Helper()
+		err := updateCache(func(data cacheData) {
+			data["dev@eu-west-1"] = map[string]cacheRecord{
+				"fresh":   {Value: []any{}, FetchedAt: now.Add(-10 * time.Minute), TTL: "1h"},
+				"recent":  {Value: []any{}, FetchedAt: now.Add(-2 * time.Hour), TTL: "24h"},
+				"expired": {Value: []any{}, FetchedAt: now.Add(-2 * time.Hour), TTL: "1h"},
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		maxAge    time.Duration
+		olderThan time.Duration
+		want      string
+	}{
+		{name: "past ttl", want: "expired"},
+		{name: "max age", maxAge: time.Hour, want: "expired recent"},
+		{name: "older than", olderThan: 5 * time.Minute, maxAge: time.Hour, want: "expired fresh recent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fill()
+			CacheMaxAge = tt.maxAge
+			removed, err := PruneCache("", tt.olderThan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, r := range removed {
+				keys = append(keys, r.Key)
+			}
+			if got := strings.Join(keys, " "); got != tt.want {
+				t.Errorf("pruned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
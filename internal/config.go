@@ -0,0 +1,320 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	yaml "go.yaml.in/yaml/v3"
+)
+
+// ProjectConfigName is the name of a new project config file, checked into a
+// repository next to the code it configures. Existing project configs may
+// also be .pcli.yml or .pcli.toml.
+const ProjectConfigName = ".pcli.yaml"
+
+// ProjectConfigPath returns the nearest project config file, found by
+// walking up from the working directory, or "" when there is none. The home
+// directory is not searched, as it holds the user's own config. Within a
+// directory .pcli.yaml, .pcli.yml and .pcli.toml are tried in that order.
+func ProjectConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		if dir == home {
+			return ""
+		}
+		if path := findConfigIn(dir, ".pcli", projectConfigExtensions); path != "" {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ApplyConfigDefaults registers the defaults of the supported settings with
+// Viper, the lowest configuration layer.
+func ApplyConfigDefaults() {
+	for _, k := range configKeys {
+		if k.Default != nil && !strings.Contains(k.Key, "*") {
+			viper.SetDefault(k.Key, k.Default)
+		}
+	}
+}
+
+// ReloadConfig re-reads the global config file and merges the project config
+// over it, so project settings take precedence.
+func ReloadConfig() error {
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	return MergeProjectConfig()
+}
+
+// MergeProjectConfig merges the project config, if there is one, over the
+// settings Viper has already loaded.
+func MergeProjectConfig() error {
+	path := ProjectConfigPath()
+	if path == "" {
+		return nil
+	}
+	data, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	return viper.MergeConfigMap(data)
+}
+
+// DeleteConfigKey removes a key (like "cache" or "contexts.prod") from the
+// file that Viper is currently using, and persists the change.
+// Works for JSON, YAML (.yml/.yaml) and TOML.
+func DeleteConfigKey(key string) error {
+	return DeleteConfigKeyIn(viper.ConfigFileUsed(), key)
+}
+
+// DeleteConfigKeyIn removes a key from the config file at path. Sections
+// left empty are removed as well.
+func DeleteConfigKeyIn(path, key string) error {
+	return updateConfigFile(path, configEdit{key: key, remove: true})
+}
+
+// deleteNested removes the key at parts from data and reports whether data
+// ended up empty.
+func deleteNested(data map[string]any, parts []string) bool {
+	if len(parts) == 1 {
+		delete(data, parts[0])
+		return len(data) == 0
+	}
+	child, ok := asStringMap(data[parts[0]])
+	if !ok {
+		return false
+	}
+	data[parts[0]] = child
+	if deleteNested(child, parts[1:]) {
+		delete(data, parts[0])
+	}
+	return len(data) == 0
+}
+
+// SetConfigKey sets a (possibly nested, dot separated) key in the file that
+// Viper is currently using, and persists the change. Unlike viper.WriteConfig
+// it only touches the given key, so flag values and defaults never leak into
+// the user's config file.
+func SetConfigKey(key string, value any) error {
+	return SetConfigKeyIn(viper.ConfigFileUsed(), key, value)
+}
+
+// SetConfigKeyIn sets a key in the config file at path, creating the file
+// if it does not exist yet.
+func SetConfigKeyIn(path, key string, value any) error {
+	return updateConfigFile(path, configEdit{key: key, value: value})
+}
+
+// LookupConfigValue returns the value of a dotted key in decoded settings.
+func LookupConfigValue(data map[string]any, key string) (any, bool) {
+	var value any = data
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		fields, ok := asStringMap(value)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// FlattenConfig returns decoded settings as dotted keys (aws.region) with
+// their values. Lists are kept whole.
+func FlattenConfig(data map[string]any) map[string]any {
+	return flattenConfig(data)
+}
+
+// getDuration reads a duration setting. Unlike viper.GetDuration it accepts
+// the d and w units, like every duration pcli parses.
+func getDuration(key string) time.Duration {
+	d, err := ParseDuration(viper.GetString(key))
+	if err != nil {
+		return viper.GetDuration(key)
+	}
+	return d
+}
+
+// ReadConfigFile decodes the config file at path into a generic map.
+func ReadConfigFile(path string) (map[string]any, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return DecodeConfig(path, raw)
+}
+
+// DecodeConfig decodes the content of a config file, choosing the format by
+// the extension of path.
+func DecodeConfig(path string, raw []byte) (map[string]any, error) {
+	format, err := ConfigFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]any{}
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("unmarshal yaml: %w", err)
+		}
+		if data == nil {
+			data = map[string]any{}
+		}
+	case "toml":
+		if err := toml.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("unmarshal toml: %w", err)
+		}
+	default:
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			return data, nil
+		}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("unmarshal json: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// encodeConfig encodes settings in the format of path.
+func encodeConfig(path string, data map[string]any) ([]byte, error) {
+	format, err := ConfigFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "yaml":
+		var out bytes.Buffer
+		enc := yaml.NewEncoder(&out)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return nil, fmt.Errorf("marshal yaml: %w", err)
+		}
+		enc.Close()
+		return out.Bytes(), nil
+	case "toml":
+		out, err := toml.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("marshal toml: %w", err)
+		}
+		return out, nil
+	default:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal json: %w", err)
+		}
+		return append(out, '\n'), nil // pretty end newline
+	}
+}
+
+// WriteConfigFile replaces the config file at path with raw, under the same
+// lock as key updates, keeping the file's permissions, and reloads the
+// configuration.
+func WriteConfigFile(path string, raw []byte) error {
+	path = resolveConfigPath(path)
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := writeConfigBytes(path, raw); err != nil {
+		return err
+	}
+	return ReloadConfig()
+}
+
+// updateConfigFile applies edit to the config file at path, keeping its
+// format and, where the format allows, its comments and key order. The
+// update holds a file lock and replaces the file atomically, so concurrent
+// pcli processes cannot interleave their writes or truncate the config. A
+// missing file is created.
+func updateConfigFile(path string, edit configEdit) error {
+	if path == "" {
+		return fmt.Errorf("no config file bound to viper (ConfigFileUsed() is empty)")
+	}
+	path = resolveConfigPath(path)
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	updated, err := applyConfigEdit(path, raw, edit)
+	if err != nil {
+		return err
+	}
+	if err := writeConfigBytes(path, updated); err != nil {
+		return err
+	}
+
+	// Reload into viper so in-memory matches disk
+	if err := ReloadConfig(); err != nil {
+		return fmt.Errorf("reload viper: %w", err)
+	}
+	return nil
+}
+
+// resolveConfigPath follows symlinks, so that writes replace the real file
+// rather than the link pointing at it.
+func resolveConfigPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// writeConfigBytes atomically writes a config file, preserving the
+// permissions of the file it replaces.
+func writeConfigBytes(path string, raw []byte) error {
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(path, raw, perm); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// asStringMap normalises the nested map types produced by the JSON and YAML
+// decoders into map[string]any.
+func asStringMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		converted := make(map[string]any, len(m))
+		for k, val := range m {
+			converted[fmt.Sprint(k)] = val
+		}
+		return converted, true
+	default:
+		return nil, false
+	}
+}
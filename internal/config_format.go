@@ -0,0 +1,375 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// ConfigFormats are the supported config file formats.
+var ConfigFormats = []string{"json", "yaml", "toml"}
+
+// configExtensions are the config file extensions looked for, in order of
+// preference.
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// projectConfigExtensions are the extensions of project config files. JSON
+// is left out, as .pcli.json is the name of the global config.
+var projectConfigExtensions = []string{".yaml", ".yml", ".toml"}
+
+// ConfigFormat returns the format of a config file, chosen by the extension
+// of path.
+func ConfigFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return "json", nil
+	case ".yml", ".yaml":
+		return "yaml", nil
+	case ".toml":
+		return "toml", nil
+	default:
+		return "", fmt.Errorf("unsupported config format: %s", ext)
+	}
+}
+
+// FindConfigFile returns the first existing config file named name (without
+// extension) in dir, trying .json, .yaml, .yml and .toml in that order. When
+// there is none it returns the .json path, where a new file is created.
+func FindConfigFile(dir, name string) string {
+	if path := findConfigIn(dir, name, configExtensions); path != "" {
+		return path
+	}
+	return filepath.Join(dir, name+".json")
+}
+
+// findConfigIn returns the first existing config file named name in dir with
+// one of extensions, or "".
+func findConfigIn(dir, name string, extensions []string) string {
+	for _, ext := range extensions {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// configEdit is a change of a single (dotted) key in a config file.
+type configEdit struct {
+	key    string
+	value  any
+	remove bool
+}
+
+// applyConfigEdit applies edit to the content of the config file at path.
+// YAML keeps its comments, and YAML and JSON keep the order of their keys;
+// TOML files are re-encoded with sorted keys and lose their comments.
+func applyConfigEdit(path string, raw []byte, edit configEdit) ([]byte, error) {
+	format, err := ConfigFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format != "toml" {
+		if doc, err := parseConfigNode(raw); err == nil {
+			parts := strings.Split(edit.key, ".")
+			if edit.remove {
+				deleteNode(doc.Content[0], parts)
+			} else if err := setNode(doc.Content[0], parts, edit.value); err != nil {
+				return nil, err
+			}
+			return encodeConfigNode(format, doc)
+		}
+		// Content the YAML parser cannot read as a document, like JSON
+		// indented with tabs, is edited without keeping the key order
+	}
+
+	data, err := DecodeConfig(path, raw)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(edit.key, ".")
+	if edit.remove {
+		deleteNested(data, parts)
+	} else {
+		setNested(data, parts, edit.value)
+	}
+	return encodeConfig(path, data)
+}
+
+// setNested sets the key at parts in data, creating sections as needed.
+func setNested(data map[string]any, parts []string, value any) {
+	parent := data
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := asStringMap(parent[part])
+		if !ok {
+			child = map[string]any{}
+		}
+		parent[part] = child
+		parent = child
+	}
+	parent[parts[len(parts)-1]] = value
+}
+
+// parseConfigNode parses YAML or JSON content into a document node whose
+// root is a mapping. Empty content gives an empty mapping.
+func parseConfigNode(raw []byte) (*yamlv3.Node, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("config is not a mapping of settings")
+	}
+	return &doc, nil
+}
+
+// setNode sets the key at parts below a mapping node, appending keys and
+// sections that do not exist yet.
+func setNode(mapping *yamlv3.Node, parts []string, value any) error {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !strings.EqualFold(mapping.Content[i].Value, parts[0]) {
+			continue
+		}
+		child := mapping.Content[i+1]
+		if len(parts) == 1 {
+			return encodeNodeValue(child, value)
+		}
+		if child.Kind != yamlv3.MappingNode {
+			*child = yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		}
+		return setNode(child, parts[1:], value)
+	}
+
+	key := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: parts[0]}
+	child := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, key, child)
+	if len(parts) == 1 {
+		return encodeNodeValue(child, value)
+	}
+	return setNode(child, parts[1:], value)
+}
+
+// encodeNodeValue replaces the value of node, keeping its comments.
+func encodeNodeValue(node *yamlv3.Node, value any) error {
+	head, line, foot := node.HeadComment, node.LineComment, node.FootComment
+	var encoded yamlv3.Node
+	if err := encoded.Encode(value); err != nil {
+		return fmt.Errorf("encode %v: %w", value, err)
+	}
+	*node = encoded
+	node.HeadComment, node.LineComment, node.FootComment = head, line, foot
+	return nil
+}
+
+// deleteNode removes the key at parts below a mapping node and reports
+// whether the mapping ended up empty. Sections left empty are removed too.
+func deleteNode(mapping *yamlv3.Node, parts []string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !strings.EqualFold(mapping.Content[i].Value, parts[0]) {
+			continue
+		}
+		child := mapping.Content[i+1]
+		if len(parts) == 1 || (child.Kind == yamlv3.MappingNode && deleteNode(child, parts[1:])) {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		}
+		break
+	}
+	return len(mapping.Content) == 0
+}
+
+// encodeConfigNode encodes a document node as YAML or as JSON.
+func encodeConfigNode(format string, doc *yamlv3.Node) ([]byte, error) {
+	if format == "json" {
+		value, err := nodeJSON(doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, value, "", "  "); err != nil {
+			return nil, fmt.Errorf("marshal json: %w", err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+
+	var out bytes.Buffer
+	enc := yamlv3.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// nodeJSON renders a node as compact JSON, keeping the order of mapping keys.
+func nodeJSON(node *yamlv3.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			value, err := nodeJSON(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	case yamlv3.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			value, err := nodeJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(']')
+	case yamlv3.AliasNode:
+		return nodeJSON(node.Alias)
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		out, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal json: %w", err)
+		}
+		buf.Write(out)
+	}
+	return buf.Bytes(), nil
+}
+
+// clearNodeStyle resets the style of a node tree, so content parsed from
+// JSON is written as block YAML rather than flow mappings of quoted strings.
+func clearNodeStyle(node *yamlv3.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
+}
+
+// ConvertConfigFile writes the config file at path in another format next to
+// it (.pcli.json becomes .pcli.yaml) and renames the original to
+// <path>.bak. Key order is kept when converting between JSON and YAML, and
+// comments when converting YAML to YAML. It returns the new path.
+func ConvertConfigFile(path, format string) (string, error) {
+	from, err := ConfigFormat(path)
+	if err != nil {
+		return "", err
+	}
+	ext := "." + format
+	if _, err := ConfigFormat(ext); err != nil {
+		return "", fmt.Errorf("unsupported format '%s' (supported: %s)", format, strings.Join(ConfigFormats, ", "))
+	}
+	if from == format {
+		return "", fmt.Errorf("%s is already %s", path, format)
+	}
+
+	target := strings.TrimSuffix(path, filepath.Ext(path)) + ext
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("read config: %w", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read config: %w", err)
+	}
+
+	var out []byte
+	doc, nodeErr := parseConfigNode(raw)
+	if from != "toml" && format != "toml" && nodeErr == nil {
+		if from == "json" {
+			clearNodeStyle(doc)
+		}
+		out, err = encodeConfigNode(format, doc)
+	} else {
+		var data map[string]any
+		if data, err = DecodeConfig(path, raw); err == nil {
+			out, err = encodeConfig(target, data)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeFileAtomic(target, out, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		os.Remove(target)
+		return "", fmt.Errorf("back up %s: %w", path, err)
+	}
+	return target, nil
+}
+
+// tomlTableLine and tomlKeyLine match table headers and key assignments of
+// TOML files.
+var (
+	tomlTableLine = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?`)
+	tomlKeyLine   = regexp.MustCompile(`^\s*([A-Za-z0-9_.\-"' ]+?)\s*=`)
+)
+
+// tomlKeyLines records the lines keys are assigned on in a TOML file, by
+// following table headers line by line.
+func tomlKeyLines(raw []byte, lines map[string]int) {
+	table := ""
+	for i, line := range strings.Split(string(raw), "\n") {
+		if m := tomlTableLine.FindStringSubmatch(line); m != nil {
+			table = tomlKey(m[1])
+			lines[table] = i + 1
+			continue
+		}
+		if m := tomlKeyLine.FindStringSubmatch(line); m != nil {
+			lines[joinKey(table, tomlKey(m[1]))] = i + 1
+		}
+	}
+}
+
+// tomlKey normalises a (possibly dotted and quoted) TOML key.
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.Trim(strings.TrimSpace(part), `"'`))
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlErrorLine returns the line a TOML decode error points at, or 0.
+func tomlErrorLine(err error) int {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, _ := decodeErr.Position()
+		return row
+	}
+	return 0
+}
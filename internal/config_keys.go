@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigType is the type of value a config key holds.
+type ConfigType string
+
+const (
+	ConfigString   ConfigType = "string"
+	ConfigBool     ConfigType = "bool"
+	ConfigInt      ConfigType = "int"
+	ConfigDuration ConfigType = "duration"
+	ConfigList     ConfigType = "list"
+)
+
+// ConfigKey describes a supported setting. Keys may contain a "*" segment
+// matching any name, e.g. contexts.*.profile.
+type ConfigKey struct {
+	Key         string
+	Type        ConfigType
+	Description string
+	// Values restricts a string key to a fixed set of values
+	Values []string
+	// Default is the value used when no layer sets the key, if any
+	Default any
+	// NoEnv keys cannot be set by environment variables, like the version
+	// that wrote a file or contexts, which are read as whole sections
+	NoEnv bool
+}
+
+// configKeys is the registry of every supported setting.
+var configKeys = []ConfigKey{
+	{Key: "version", Type: ConfigString, Description: "Version of pcli that wrote the config file", NoEnv: true},
+	{Key: "verbose", Type: ConfigBool, Description: "Enable verbose output by default", Default: false},
+	{Key: "quiet", Type: ConfigBool, Description: "Suppress non-essential output by default", Default: false},
+	{Key: "current_context", Type: ConfigString, Description: "Context commands run against unless --context is given"},
+	{Key: "aws.profile", Type: ConfigString, Description: "Default AWS profile"},
+	{Key: "aws.region", Type: ConfigString, Description: "Default AWS region"},
+	{Key: "sso.start_url", Type: ConfigString, Description: "AWS SSO start URL"},
+	{Key: "sso.region", Type: ConfigString, Description: "Region of the AWS SSO instance"},
+	{Key: "contexts.*.profile", Type: ConfigString, Description: "AWS profile of a context", NoEnv: true},
+	{Key: "contexts.*.region", Type: ConfigString, Description: "AWS region of a context", NoEnv: true},
+	{Key: "contexts.*.role_arn", Type: ConfigString, Description: "Role assumed by a context", NoEnv: true},
+	{Key: "contexts.*.external_id", Type: ConfigString, Description: "External ID for assuming the role", NoEnv: true},
+	{Key: "contexts.*.session_name", Type: ConfigString, Description: "Session name for assuming the role", NoEnv: true},
+	{Key: "contexts.*.mfa_serial", Type: ConfigString, Description: "MFA device required by the role", NoEnv: true},
+	{Key: "contexts.*.sso_start_url", Type: ConfigString, Description: "AWS SSO start URL of a context", NoEnv: true},
+	{Key: "contexts.*.sso_region", Type: ConfigString, Description: "Region of the AWS SSO instance of a context", NoEnv: true},
+	{Key: "contexts.*.sso_account_id", Type: ConfigString, Description: "AWS account signed in to via SSO", NoEnv: true},
+	{Key: "contexts.*.sso_role_name", Type: ConfigString, Description: "Role signed in to via SSO", NoEnv: true},
+	{Key: "contexts.*.log_group_prefix", Type: ConfigString, Description: "Log group prefix completion is narrowed to", NoEnv: true},
+	{Key: "contexts.*.source", Type: ConfigString, Description: "Log source backend", Values: SupportedSources, NoEnv: true},
+	{Key: "cache_ttl.*", Type: ConfigDuration, Description: "How long a cache key stays fresh"},
+	{Key: "cache_refresh.concurrency", Type: ConfigInt, Description: "Cache providers refreshed in parallel", Default: DefaultRefreshConcurrency},
+	{Key: "cache_refresh.timeout", Type: ConfigDuration, Description: "Time limit per cache provider refresh", Default: DefaultRefreshTimeout.String()},
+	{Key: "cache_seed", Type: ConfigString, Description: "Seed file imported once into the cache"},
+	{Key: "cache_warm.contexts", Type: ConfigList, Description: "Contexts kept fresh by the cache warmer"},
+	{Key: "log_groups", Type: ConfigList, Description: "Log groups of the project, offered first by completion"},
+	{Key: "queries.*", Type: ConfigString, Description: "Saved CloudWatch Logs Insights query of the project"},
+	{Key: "aliases.*", Type: ConfigString, Description: "Command line run by 'pcli <name>', with {{1}} or {{@}} for arguments", NoEnv: true},
+	{Key: "macros.*", Type: ConfigList, Description: "Command lines run one after the other by 'pcli <name>'", NoEnv: true},
+}
+
+// ConfigKeys returns the registry of supported settings sorted by key.
+func ConfigKeys() []ConfigKey {
+	keys := append([]ConfigKey(nil), configKeys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// LookupConfigKey finds the registry entry matching key, which may name a
+// concrete instance of a wildcard key (contexts.prod.profile).
+func LookupConfigKey(key string) (ConfigKey, bool) {
+	key = strings.ToLower(key)
+	parts := strings.Split(key, ".")
+	for _, k := range configKeys {
+		pattern := strings.Split(k.Key, ".")
+		if len(pattern) != len(parts) {
+			continue
+		}
+		match := true
+		for i := range pattern {
+			if pattern[i] != "*" && pattern[i] != parts[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k, true
+		}
+	}
+	return ConfigKey{}, false
+}
+
+// UnknownConfigKeyError reports a key missing from the registry, with the
+// closest supported keys.
+func UnknownConfigKeyError(key string) error {
+	// Fill wildcards from the key itself, so contexts.prod.profle suggests
+	// contexts.prod.profile
+	parts := strings.Split(strings.ToLower(key), ".")
+	var candidates []string
+	for _, k := range configKeys {
+		pattern := strings.Split(k.Key, ".")
+		for i := range pattern {
+			if pattern[i] == "*" && i < len(parts) {
+				pattern[i] = parts[i]
+			}
+		}
+		candidates = append(candidates, strings.Join(pattern, "."))
+	}
+	if suggestions := Suggest(key, candidates); len(suggestions) > 0 {
+		return fmt.Errorf("unknown config key '%s' (did you mean '%s'?)", key, suggestions[0])
+	}
+	return fmt.Errorf("unknown config key '%s'", key)
+}
+
+// IsConfigSection reports whether key is a prefix of supported keys, like
+// "aws" or "contexts.prod", rather than a setting itself.
+func IsConfigSection(key string) bool {
+	parts := strings.Split(strings.ToLower(key), ".")
+	for _, k := range configKeys {
+		pattern := strings.Split(k.Key, ".")
+		if len(pattern) <= len(parts) {
+			continue
+		}
+		match := true
+		for i := range parts {
+			if pattern[i] != "*" && pattern[i] != parts[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseConfigValue converts a value given on the command line into the type
+// of key, e.g. "true" for a bool key or "a,b" for a list. Durations accept d
+// and w units and are stored in Go's duration syntax.
+func ParseConfigValue(key, raw string) (any, error) {
+	k, ok := LookupConfigKey(key)
+	if !ok {
+		return nil, UnknownConfigKeyError(key)
+	}
+
+	switch k.Type {
+	case ConfigBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got '%s'", key, raw)
+		}
+		return b, nil
+	case ConfigInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number, got '%s'", key, raw)
+		}
+		return n, nil
+	case ConfigDuration:
+		d, err := ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a duration like 30s, 6h or 7d, got '%s'", key, raw)
+		}
+		return d.String(), nil
+	case ConfigList:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		if err := checkConfigEnum(k, key, raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+}
+
+// CheckConfigValue validates a value read from a config file against the
+// type of key.
+func CheckConfigValue(key string, value any) error {
+	k, ok := LookupConfigKey(key)
+	if !ok {
+		return UnknownConfigKeyError(key)
+	}
+
+	switch k.Type {
+	case ConfigBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be true or false, got %s", key, typeName(value))
+		}
+	case ConfigInt:
+		switch n := value.(type) {
+		case int, int64:
+		case float64:
+			if n != math.Trunc(n) {
+				return fmt.Errorf("%s must be a whole number, got %v", key, n)
+			}
+		default:
+			return fmt.Errorf("%s must be a whole number, got %s", key, typeName(value))
+		}
+	case ConfigDuration:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a duration string like \"6h\", got %s", key, typeName(value))
+		}
+		if _, err := ParseDuration(s); err != nil {
+			return fmt.Errorf("%s must be a duration like 30s, 6h or 7d, got '%s'", key, s)
+		}
+	case ConfigList:
+		list, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be a list, got %s", key, typeName(value))
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return fmt.Errorf("%s must be a list of strings, got a %s item", key, typeName(item))
+			}
+		}
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string, got %s", key, typeName(value))
+		}
+		return checkConfigEnum(k, key, s)
+	}
+	return nil
+}
+
+// checkConfigEnum checks value against the allowed values of k, if any.
+func checkConfigEnum(k ConfigKey, key, value string) error {
+	if len(k.Values) == 0 {
+		return nil
+	}
+	for _, v := range k.Values {
+		if v == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got '%s'", key, strings.Join(k.Values, ", "), value)
+}
+
+// flattenConfig turns nested settings into dotted keys. Lists and scalars
+// are leaves; empty sections are dropped.
+func flattenConfig(data map[string]any) map[string]any {
+	flat := map[string]any{}
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		if fields, ok := asStringMap(value); ok {
+			for k, v := range fields {
+				walk(prefix+"."+k, v)
+			}
+			return
+		}
+		flat[strings.TrimPrefix(prefix, ".")] = value
+	}
+	walk("", data)
+	return flat
+}
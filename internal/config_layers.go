@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Configuration layers, from lowest to highest precedence.
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerProject = "project"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+// ConfigSetting is an effective setting together with where it comes from.
+type ConfigSetting struct {
+	Key   string
+	Value any
+	// Layer is one of the Layer* constants
+	Layer string
+	// Source is the file, environment variable or flag within the layer
+	Source string
+}
+
+// configFlags maps settings to the global flags that override them.
+var configFlags = map[string]string{
+	"verbose":         "verbose",
+	"quiet":           "quiet",
+	"current_context": "context",
+	"aws.profile":     "profile",
+	"aws.region":      "region",
+}
+
+// EnvPrefix is the prefix of environment variables that override settings.
+const EnvPrefix = "PCLI"
+
+// envKeyReplacer turns nested keys into environment variable names.
+var envKeyReplacer = strings.NewReplacer(".", "_")
+
+// BindConfigEnv lets environment variables override settings: PCLI_ followed
+// by the upper case key with dots as underscores, e.g. PCLI_AWS_REGION for
+// aws.region. Only keys of the registry that allow it are bound, so NoEnv
+// keys like the version or contexts never come from the environment.
+// Variables of wildcard keys, like PCLI_CACHE_TTL_LOG_GROUPS, are bound when
+// they are set.
+func BindConfigEnv() {
+	for _, k := range configKeys {
+		if !k.NoEnv && !strings.Contains(k.Key, "*") {
+			viper.BindEnv(k.Key, ConfigEnvVar(k.Key))
+		}
+	}
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, EnvPrefix+"_") {
+			continue
+		}
+		if key, ok := configEnvKey(name); ok {
+			viper.BindEnv(key, name)
+		}
+	}
+}
+
+// ValidateConfigEnv checks the values of the environment variables that
+// override settings. Problems name the variable in place of a file.
+func ValidateConfigEnv() []ConfigProblem {
+	var problems []ConfigProblem
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if value == "" || !strings.HasPrefix(name, EnvPrefix+"_") {
+			continue
+		}
+		key, ok := configEnvKey(name)
+		if !ok {
+			continue
+		}
+		if _, err := ParseConfigValue(key, value); err != nil {
+			problems = append(problems, ConfigProblem{File: name, Key: key, Message: err.Error()})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].File < problems[j].File })
+	return problems
+}
+
+// ConfigEnvVar returns the environment variable that overrides key. For a
+// wildcard key the "*" is kept, e.g. PCLI_CACHE_TTL_*.
+func ConfigEnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// configEnvKey returns the setting an environment variable overrides, if
+// any. Variables of wildcard keys give the concrete key, e.g.
+// PCLI_CACHE_TTL_LOG_GROUPS gives cache_ttl.log_groups.
+func configEnvKey(name string) (string, bool) {
+	for _, k := range configKeys {
+		if !k.NoEnv && name == ConfigEnvVar(k.Key) {
+			return k.Key, true
+		}
+	}
+	for _, k := range configKeys {
+		if k.NoEnv {
+			continue
+		}
+		prefix, suffix, wildcard := strings.Cut(ConfigEnvVar(k.Key), "*")
+		if wildcard && len(name) > len(prefix)+len(suffix) && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			name := strings.ToLower(name[len(prefix) : len(name)-len(suffix)])
+			return strings.Replace(k.Key, "*", name, 1), true
+		}
+	}
+	return "", false
+}
+
+// EffectiveConfig returns every setting that has a value, sorted by key,
+// each from the highest layer that sets it: defaults < global config <
+// project config < environment < flags. flags are the global flags of the
+// command line.
+func EffectiveConfig(flags *pflag.FlagSet) []ConfigSetting {
+	settings := map[string]ConfigSetting{}
+	set := func(key string, value any, layer, source string) {
+		settings[key] = ConfigSetting{Key: key, Value: value, Layer: layer, Source: source}
+	}
+
+	for _, k := range configKeys {
+		if k.Default != nil && !strings.Contains(k.Key, "*") {
+			set(k.Key, k.Default, LayerDefault, "")
+		}
+	}
+
+	files := []struct{ layer, path string }{
+		{LayerGlobal, viper.ConfigFileUsed()},
+		{LayerProject, ProjectConfigPath()},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		data, err := ReadConfigFile(file.path)
+		if err != nil {
+			continue
+		}
+		for key, value := range flattenConfig(data) {
+			set(strings.ToLower(key), value, file.layer, file.path)
+		}
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if value == "" || !strings.HasPrefix(name, EnvPrefix+"_") {
+			continue
+		}
+		if key, ok := configEnvKey(name); ok {
+			set(key, value, LayerEnv, name)
+		}
+	}
+
+	if flags != nil {
+		for key, name := range configFlags {
+			if f := flags.Lookup(name); f != nil && f.Changed {
+				set(key, f.Value.String(), LayerFlag, "--"+name)
+			}
+		}
+	}
+
+	result := make([]ConfigSetting, 0, len(settings))
+	for _, s := range settings {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// ConfigFileDefining returns the config file the effective value of key
+// comes from: the project config if it sets the key, else the global config
+// if it does, else "".
+func ConfigFileDefining(key string) string {
+	for _, path := range []string{ProjectConfigPath(), viper.ConfigFileUsed()} {
+		if path == "" {
+			continue
+		}
+		data, err := ReadConfigFile(path)
+		if err != nil {
+			continue
+		}
+		if _, ok := LookupConfigValue(data, key); ok {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ConfigVersion is the version of the config file format written by this
+// pcli. It is stored in the "version" key and only changes with a release
+// that needs a migration of existing files.
+const ConfigVersion = "v0.3.0"
+
+// ConfigMigration upgrades config files written before Version.
+type ConfigMigration struct {
+	// Version is the config version the migration upgrades to
+	Version     string
+	Description string
+	// Migrate inspects the decoded settings and returns what to change. It
+	// must not change anything itself.
+	Migrate func(data map[string]any) (migrationStep, error)
+}
+
+// migrationStep is what a migration changes: edits of the config file, with
+// a line describing each change for the summary, and changes elsewhere, like
+// moving cached data into the cache file. Those are applied only once the
+// config file is backed up and written, so a failed write leaves everything
+// as it was and the migration runs again.
+type migrationStep struct {
+	edits   []configEdit
+	changes []string
+	// apply makes the changes outside the config file and describes them
+	apply func() ([]string, error)
+}
+
+// configMigrations are applied in order to files older than their Version.
+// No released version renamed a key or changed the layout of contexts: files
+// of v0.1.0 held only version, aws.profile, aws.region and the cache, and
+// contexts have kept their contexts.<name>.<setting> form since they were
+// added. A future rename is a migration returning a remove and a set edit.
+var configMigrations = []ConfigMigration{
+	{
+		Version:     "v0.2.0",
+		Description: "Move cached data out of the config file",
+		Migrate:     migrateLegacyCache,
+	},
+	{
+		Version:     "v0.3.0",
+		Description: "Keep cached data of role contexts apart",
+		Migrate:     migrateRoleScopes,
+	},
+}
+
+// migrateLegacyCache moves the "cache" section, which older versions of pcli
+// kept in the config file, into the cache file.
+func migrateLegacyCache(data map[string]any) (migrationStep, error) {
+	remove := []configEdit{{key: "cache", remove: true}}
+	legacy, ok := asStringMap(data["cache"])
+	if !ok {
+		if _, exists := data["cache"]; !exists {
+			return migrationStep{}, nil
+		}
+		return migrationStep{edits: remove, changes: []string{"Removed an unreadable 'cache' value"}}, nil
+	}
+	return migrationStep{edits: remove, apply: func() ([]string, error) {
+		if err := importLegacyCache(legacy); err != nil {
+			return nil, err
+		}
+		path, err := CachePath()
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Moved cached data to %s", path)}, nil
+	}}, nil
+}
+
+// migrateRoleScopes moves cached data of contexts that assume a role or use
+// SSO out of the namespace of their profile, which older versions of pcli
+// shared between all accounts reached through it.
+func migrateRoleScopes(data map[string]any) (migrationStep, error) {
+	settings := viper.New()
+	if err := settings.MergeConfigMap(data); err != nil {
+		return migrationStep{}, err
+	}
+	contexts := map[string]Context{}
+	if err := settings.UnmarshalKey("contexts", &contexts); err != nil {
+		// Reported by validation; there is nothing to move without contexts
+		return migrationStep{}, nil
+	}
+
+	defaults := Target{Profile: settings.GetString("aws.profile"), Region: settings.GetString("aws.region")}
+	scopes := map[string]string{}
+	for name, ctx := range contexts {
+		ctx.Name = strings.ToLower(name)
+		if t := defaults.withContext(ctx); t.identity() != "" {
+			scopes[ctx.Name] = t.Scope()
+		}
+	}
+
+	if len(scopes) == 0 {
+		return migrationStep{}, nil
+	}
+	return migrationStep{apply: func() ([]string, error) {
+		moved, dropped, err := moveRoleScopes(scopes)
+		if err != nil {
+			return nil, err
+		}
+		var changes []string
+		if moved > 0 {
+			changes = append(changes, fmt.Sprintf("Moved %d cache entries of role and SSO contexts to their own namespace", moved))
+		}
+		if dropped > 0 {
+			changes = append(changes, fmt.Sprintf("Dropped %d cache entries that could belong to several accounts; they are fetched again", dropped))
+		}
+		return changes, nil
+	}}, nil
+}
+
+// ConfigMigrationResult summarises the migration of a config file.
+type ConfigMigrationResult struct {
+	Path string
+	From string
+	To   string
+	// Backup is the copy of the file before migration
+	Backup  string
+	Changes []string
+}
+
+// MigrateConfig upgrades the global config file when it was written by an
+// older pcli: it runs the pending migrations in order, backs the file up to
+// <file>.<old version>.bak and records ConfigVersion in it. Comments and
+// key order are kept where the format allows. It returns nil when there was
+// nothing to migrate. Project configs are checked into repositories and are
+// never rewritten.
+func MigrateConfig(path string) (*ConfigMigrationResult, error) {
+	if path == "" {
+		return nil, nil
+	}
+	result, err := migrateConfigFile(resolveConfigPath(path))
+	if err != nil || result == nil {
+		return result, err
+	}
+	return result, ReloadConfig()
+}
+
+// migrateConfigFile runs the pending migrations of the config file at path
+// under its lock.
+func migrateConfigFile(path string) (*ConfigMigrationResult, error) {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	data, err := DecodeConfig(path, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files without a version predate versioning, or were written by hand
+	from, _ := data["version"].(string)
+	if from != "" && CompareVersions(from, ConfigVersion) >= 0 {
+		return nil, nil
+	}
+
+	result := &ConfigMigrationResult{Path: path, From: from, To: ConfigVersion}
+	// Steps with changes outside the config file, made after writing it
+	type pendingStep struct {
+		migration ConfigMigration
+		step      migrationStep
+	}
+	var (
+		edits   []configEdit
+		pending []pendingStep
+	)
+	for _, m := range configMigrations {
+		if from != "" && CompareVersions(from, m.Version) >= 0 {
+			continue
+		}
+		step, err := m.Migrate(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.ToLower(m.Description), err)
+		}
+		edits = append(edits, step.edits...)
+		result.Changes = append(result.Changes, step.changes...)
+		if step.apply != nil {
+			pending = append(pending, pendingStep{m, step})
+		}
+	}
+	if len(edits) == 0 && len(pending) == 0 && from == "" {
+		// Nothing to do, and no reason to add a version to a hand-written file
+		return nil, nil
+	}
+
+	updated := raw
+	for _, edit := range append(edits, configEdit{key: "version", value: ConfigVersion}) {
+		if updated, err = applyConfigEdit(path, updated, edit); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(edits) > 0 {
+		version := from
+		if version == "" {
+			version = "unversioned"
+		}
+		result.Backup = path + "." + version + ".bak"
+		if err := writeFileAtomic(result.Backup, raw, info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("back up config: %w", err)
+		}
+	}
+	if err := writeConfigBytes(path, updated); err != nil {
+		return nil, err
+	}
+
+	for _, p := range pending {
+		changes, err := p.step.apply()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.ToLower(p.migration.Description), err)
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+	return result, nil
+}
+
+// NewerConfigVersion returns the version recorded in the config file at path
+// when it is newer than ConfigVersion, meaning a newer pcli wrote the file
+// and this one may not understand all of its settings. It returns "" when
+// the file is current, older, unversioned or unreadable.
+func NewerConfigVersion(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := ReadConfigFile(path)
+	if err != nil {
+		return ""
+	}
+	version, _ := data["version"].(string)
+	if version == "" || CompareVersions(version, ConfigVersion) <= 0 {
+		return ""
+	}
+	return version
+}
+
+// CompareVersions compares two versions like v0.1.0 or 1.2, returning -1, 0
+// or +1. Missing parts count as 0 and pre-release or build suffixes are
+// ignored; versions that are not numeric compare as equal.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts returns the numeric parts of a version.
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,453 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// TestValidateConfigBytes checks that problems point at the offending line
+// and suggest the closest supported key, for both JSON and YAML.
+func TestValidateConfigBytes(t *testing.T) {
+	tests := []struct {
+		path string
+		raw  string
+		want []string
+	}{
+		{
+			path: "config.json",
+			raw:  "{\n  \"aws\": {\n    \"regoin\": \"eu-west-1\"\n  },\n  \"cache_refresh\": {\"concurrency\": \"8\"}\n}\n",
+			want: []string{
+				"config.json:3: unknown config key 'aws.regoin' (did you mean 'aws.region'?)",
+				"config.json:5: cache_refresh.concurrency must be a whole number, got string",
+			},
+		},
+		{
+			path: ".pcli.yaml",
+			raw:  "contexts:\n  prod:\n    profle: prod\ncache_ttl:\n  log_groups: 7d\n",
+			want: []string{
+				".pcli.yaml:3: unknown config key 'contexts.prod.profle' (did you mean 'contexts.prod.profile'?)",
+			},
+		},
+		{
+			path: "broken.json",
+			raw:  "{\n  \"aws\": {\n    \"region\" \"x\"\n",
+			want: []string{"broken.json:3: unmarshal json: invalid character"},
+		},
+	}
+
+	for _, tt := range tests {
+		problems := ValidateConfigBytes(tt.path, []byte(tt.raw))
+		if len(problems) != len(tt.want) {
+			t.Errorf("%s: got %d problems %v, want %d", tt.path, len(problems), problems, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if got := problems[i].String(); !strings.HasPrefix(got, want) {
+				t.Errorf("%s: problem %d = %q, want prefix %q", tt.path, i, got, want)
+			}
+		}
+	}
+}
+
+// TestApplyConfigEdit checks that setting and removing keys keeps the
+// comments of YAML files and the key order of YAML and JSON files.
+func TestApplyConfigEdit(t *testing.T) {
+	tests := []struct {
+		path string
+		raw  string
+		edit configEdit
+		want string
+	}{
+		{
+			path: ".pcli.yaml",
+			raw:  "# team settings\nversion: v0.1.0\naws:\n  region: us-east-1 # primary\n  profile: dev\n",
+			edit: configEdit{key: "aws.region", value: "eu-west-1"},
+			want: "# team settings\nversion: v0.1.0\naws:\n  region: eu-west-1 # primary\n  profile: dev\n",
+		},
+		{
+			path: ".pcli.yaml",
+			raw:  "version: v0.1.0\ncache_ttl:\n  log_groups: 6h\nquiet: true\n",
+			edit: configEdit{key: "cache_ttl.log_groups", remove: true},
+			want: "version: v0.1.0\nquiet: true\n",
+		},
+		{
+			path: "config.json",
+			raw:  "{\n  \"version\": \"v0.1.0\",\n  \"aws\": {\"region\": \"\"}\n}\n",
+			edit: configEdit{key: "cache_warm.contexts", value: []string{"prod"}},
+			want: "{\n  \"version\": \"v0.1.0\",\n  \"aws\": {\n    \"region\": \"\"\n  },\n  \"cache_warm\": {\n    \"contexts\": [\n      \"prod\"\n    ]\n  }\n}\n",
+		},
+		{
+			path: "config.toml",
+			raw:  "version = 'v0.1.0'\n",
+			edit: configEdit{key: "aws.region", value: "eu-west-1"},
+			want: "version = 'v0.1.0'\n\n[aws]\nregion = 'eu-west-1'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := applyConfigEdit(tt.path, []byte(tt.raw), tt.edit)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestMigrateConfigFile checks that an old config file has its cache moved
+// out and its version bumped, keeping comments, with a backup of the original.
+func TestMigrateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	path := filepath.Join(dir, ".pcli.yaml")
+	original := "version: v0.1.0 # written by pcli\naws:\n  region: eu-west-1\ncache:\n  log_groups:\n    - /app/api\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := migrateConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.From != "v0.1.0" || len(result.Changes) != 1 {
+		t.Fatalf("got result %+v, want one change from v0.1.0", result)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "version: v0.3.0 # written by pcli\naws:\n  region: eu-west-1\n"
+	if string(got) != want {
+		t.Errorf("migrated config =\n%s\nwant\n%s", got, want)
+	}
+	if backup, _ := os.ReadFile(path + ".v0.1.0.bak"); string(backup) != original {
+		t.Errorf("backup = %q, want the original file", backup)
+	}
+
+	// A migrated file is left alone
+	if result, err := migrateConfigFile(path); err != nil || result != nil {
+		t.Errorf("second migration = %+v, %v, want nothing to do", result, err)
+	}
+}
+
+// TestMigrateConfigFileVersionOnly checks that a file needing no edits only
+// gets its version bumped, without a backup, and that a role context's cache
+// entries move out of its profile's namespace on the way.
+func TestMigrateConfigFileVersionOnly(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	role := Target{Context: "prod", Profile: "shared", RoleARN: "arn:aws:iam::111111111111:role/Admin"}
+	legacy := Target{Profile: "shared"}.Scope()
+	err := updateCache(func(data cacheData) {
+		data[legacy] = map[string]cacheRecord{"log_groups": {Value: []any{}, TTL: "1h", Context: "prod"}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, ".pcli.json")
+	original := `{"version": "v0.2.0", "contexts": {"prod": {"profile": "shared", "role_arn": "` + role.RoleARN + `"}}}`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := migrateConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.Backup != "" || len(result.Changes) != 1 {
+		t.Fatalf("got result %+v, want one change and no backup", result)
+	}
+	if got := NewerConfigVersion(path); got != "" {
+		t.Errorf("migrated file reports newer version %s", got)
+	}
+	data, err := ReadConfigFile(path)
+	if err != nil || data["version"] != ConfigVersion {
+		t.Errorf("version = %v (%v), want %s", data["version"], err, ConfigVersion)
+	}
+
+	cache, err := loadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache[role.Scope()]["log_groups"]; !ok {
+		t.Errorf("log_groups not moved to %s: %v", role.Scope(), cache)
+	}
+}
+
+func TestNewerConfigVersion(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"version: v0.1.0\n":   "",
+		"version: v0.2.0\n":   "",
+		"aws:\n  region: x\n": "",
+		"version: v0.10.0\n":  "v0.10.0",
+	}
+	for content, want := range tests {
+		path := filepath.Join(dir, ".pcli.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := NewerConfigVersion(path); got != want {
+			t.Errorf("NewerConfigVersion(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
+
+// writeFiles creates files with content below root, with their directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadTestConfig loads the global config at path like pcli does: defaults
+// below it, then the environment and the project config of the working
+// directory. Viper is reset when the test ends.
+func loadTestConfig(t *testing.T, path string) {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ApplyConfigDefaults()
+	BindConfigEnv()
+	if err := MergeProjectConfig(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestProjectConfigPath checks that the nearest project config above the
+// working directory is found, and that the search stops at the home
+// directory, whose .pcli file is the global config.
+func TestProjectConfigPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		cwd   string
+		want  string
+	}{
+		{name: "in working directory", files: []string{"repo/.pcli.yml"}, cwd: "repo", want: "repo/.pcli.yml"},
+		{name: "in a parent", files: []string{"repo/.pcli.yaml"}, cwd: "repo/svc/api", want: "repo/.pcli.yaml"},
+		{name: "nearest wins", files: []string{"repo/.pcli.yaml", "repo/svc/.pcli.toml"}, cwd: "repo/svc/api", want: "repo/svc/.pcli.toml"},
+		{name: "home is global", files: []string{".pcli.yaml"}, cwd: "repo/svc"},
+		{name: "json is global only", files: []string{"repo/.pcli.json"}, cwd: "repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("HOME", home)
+			files := map[string]string{filepath.Join(tt.cwd, "README"): ""}
+			for _, name := range tt.files {
+				files[name] = ""
+			}
+			writeFiles(t, home, files)
+			t.Chdir(filepath.Join(home, tt.cwd))
+
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(home, tt.want)
+			}
+			if got := ProjectConfigPath(); got != want {
+				t.Errorf("ProjectConfigPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// TestConfigPrecedence checks that every setting comes from the highest
+// layer setting it: default < global < project < env < flag, both in
+// EffectiveConfig and in the values Viper returns.
+func TestConfigPrecedence(t *testing.T) {
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	writeFiles(t, home, map[string]string{
+		".pcli.yaml":      "aws:\n  profile: global-profile\n  region: global-region\nsso:\n  region: global-sso\ncache_refresh:\n  concurrency: 2\n",
+		"repo/.pcli.yaml": "aws:\n  region: project-region\nsso:\n  region: project-sso\ncache_refresh:\n  concurrency: 8\n",
+	})
+	t.Chdir(filepath.Join(home, "repo"))
+	t.Setenv("PCLI_SSO_REGION", "env-sso")
+	t.Setenv("PCLI_CACHE_TTL_LOG_GROUPS", "2h")
+	loadTestConfig(t, filepath.Join(home, ".pcli.yaml"))
+
+	flags := pflag.NewFlagSet("pcli", pflag.ContinueOnError)
+	flags.String("region", "", "")
+	flags.String("profile", "", "")
+	if err := flags.Parse([]string{"--region", "flag-region"}); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := map[string]ConfigSetting{}
+	for _, s := range EffectiveConfig(flags) {
+		settings[s.Key] = s
+	}
+	tests := []struct {
+		key, layer, value string
+		// viper is the value Viper returns, which flags do not reach
+		viper string
+	}{
+		{key: "cache_refresh.timeout", layer: LayerDefault, value: DefaultRefreshTimeout.String()},
+		{key: "aws.profile", layer: LayerGlobal, value: "global-profile"},
+		{key: "cache_refresh.concurrency", layer: LayerProject, value: "8"},
+		{key: "sso.region", layer: LayerEnv, value: "env-sso"},
+		{key: "cache_ttl.log_groups", layer: LayerEnv, value: "2h"},
+		{key: "aws.region", layer: LayerFlag, value: "flag-region", viper: "project-region"},
+	}
+	for _, tt := range tests {
+		s, ok := settings[tt.key]
+		if !ok {
+			t.Errorf("%s missing from the effective config", tt.key)
+			continue
+		}
+		if s.Layer != tt.layer || fmt.Sprint(s.Value) != tt.value {
+			t.Errorf("%s = %v from %s, want %s from %s", tt.key, s.Value, s.Layer, tt.value, tt.layer)
+		}
+		want := tt.value
+		if tt.viper != "" {
+			want = tt.viper
+		}
+		if got := viper.GetString(tt.key); got != want {
+			t.Errorf("viper %s = %q, want %q", tt.key, got, want)
+		}
+	}
+	if got, want := ConfigFileDefining("aws.region"), filepath.Join(home, "repo", ".pcli.yaml"); got != want {
+		t.Errorf("ConfigFileDefining(aws.region) = %q, want %q", got, want)
+	}
+	if got, want := ConfigFileDefining("aws.profile"), filepath.Join(home, ".pcli.yaml"); got != want {
+		t.Errorf("ConfigFileDefining(aws.profile) = %q, want %q", got, want)
+	}
+}
+
+// TestBindConfigEnv checks that PCLI_ variables override the values of
+// config files, and that keys which may not come from the environment,
+// like the version, ignore them.
+func TestBindConfigEnv(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(home)
+	writeFiles(t, home, map[string]string{
+		".pcli.yaml": "version: v0.3.0\naws:\n  region: eu-west-1\ncontexts:\n  prod:\n    region: us-east-1\n",
+	})
+	t.Setenv("PCLI_AWS_REGION", "ap-south-1")
+	t.Setenv("PCLI_QUIET", "true")
+	t.Setenv("PCLI_VERSION", "v9.9.9")
+	t.Setenv("PCLI_CONTEXTS_PROD_REGION", "sa-east-1")
+	loadTestConfig(t, filepath.Join(home, ".pcli.yaml"))
+
+	tests := map[string]string{
+		"aws.region":            "ap-south-1",
+		"quiet":                 "true",
+		"version":               "v0.3.0",
+		"contexts.prod.region":  "us-east-1",
+		"cache_refresh.timeout": DefaultRefreshTimeout.String(),
+		"cache_ttl.log_groups":  "",
+		"sso.start_url":         "",
+	}
+	for key, want := range tests {
+		if got := viper.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if !viper.GetBool("quiet") {
+		t.Error("PCLI_QUIET=true not read as a bool")
+	}
+}
+
+// TestValidateConfigEnv checks that invalid values of PCLI_ variables are
+// reported, and that other variables are left alone.
+func TestValidateConfigEnv(t *testing.T) {
+	t.Setenv("PCLI_QUIET", "maybe")
+	t.Setenv("PCLI_CACHE_REFRESH_CONCURRENCY", "many")
+	t.Setenv("PCLI_CACHE_TTL_LOG_GROUPS", "soon")
+	t.Setenv("PCLI_AWS_REGION", "eu-west-1")
+	t.Setenv("PCLI_CACHE_REFRESH_TIMEOUT", "2m")
+	t.Setenv("PCLI_VERSION", "not checked")
+	t.Setenv("PCLI_BIN", "/usr/local/bin/pcli")
+
+	var got []string
+	for _, problem := range ValidateConfigEnv() {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		"PCLI_CACHE_REFRESH_CONCURRENCY: cache_refresh.concurrency must be a whole number, got 'many'",
+		"PCLI_CACHE_TTL_LOG_GROUPS: cache_ttl.log_groups must be a duration like 30s, 6h or 7d, got 'soon'",
+		"PCLI_QUIET: quiet must be true or false, got 'maybe'",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestMigrateConfigFileWriteFailure checks that cached data is only moved
+// once the config file is backed up and written, so a failed write leaves
+// both as they were and the next run migrates again.
+func TestMigrateConfigFileWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	path := filepath.Join(dir, ".pcli.yaml")
+	original := "version: v0.1.0\ncache:\n  log_groups:\n    - /app/api\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A directory in the way of the backup makes writing it fail
+	backup := path + ".v0.1.0.bak"
+	if err := os.Mkdir(backup, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrateConfigFile(path); err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+	if got, _ := os.ReadFile(path); string(got) != original {
+		t.Errorf("config after failed migration =\n%s", got)
+	}
+	cachePath, err := CachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cachePath); err == nil {
+		t.Error("cache written although the config was not migrated")
+	}
+
+	if err := os.Remove(backup); err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrateConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || len(result.Changes) != 1 || !strings.HasPrefix(result.Changes[0], "Moved cached data") {
+		t.Fatalf("result = %+v, want the cache moved", result)
+	}
+	data, err := loadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Error("cached data not moved on the second run")
+	}
+}
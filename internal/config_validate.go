@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// ConfigProblem is an issue found while validating a config file.
+type ConfigProblem struct {
+	// File is the config file, or the environment variable, at fault
+	File string
+	// Line is the line of the offending key, 0 when unknown
+	Line    int
+	Key     string
+	Message string
+}
+
+// String renders the problem like a compiler error: file:line: message.
+func (p ConfigProblem) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+	}
+	return location + ": " + p.Message
+}
+
+// ValidateConfigFile checks the config file at path for syntax errors,
+// unknown keys and values of the wrong type. It only returns an error when
+// the file cannot be read.
+func ValidateConfigFile(path string) ([]ConfigProblem, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return ValidateConfigBytes(path, raw), nil
+}
+
+// ValidateConfigBytes is ValidateConfigFile for content that is not (yet)
+// saved at path, e.g. a file being edited. Problems are sorted by line.
+func ValidateConfigBytes(path string, raw []byte) []ConfigProblem {
+	data, err := DecodeConfig(path, raw)
+	if err != nil {
+		return []ConfigProblem{{File: path, Line: syntaxErrorLine(raw, err), Message: err.Error()}}
+	}
+
+	lines := configKeyLines(path, raw)
+	var problems []ConfigProblem
+	for key, value := range flattenConfig(data) {
+		if err := checkSetting(key, value); err != nil {
+			problems = append(problems, ConfigProblem{
+				File:    path,
+				Line:    lines[strings.ToLower(key)],
+				Key:     key,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Key < problems[j].Key
+	})
+	return problems
+}
+
+// checkSetting validates one flattened setting. A plain value where a
+// section of settings belongs gets its own message rather than "unknown key".
+func checkSetting(key string, value any) error {
+	if _, ok := LookupConfigKey(key); !ok && IsConfigSection(key) {
+		return fmt.Errorf("%s must be a section of settings, got %s", key, typeName(value))
+	}
+	if section, name, ok := strings.Cut(strings.ToLower(key), "."); ok && (section == "aliases" || section == "macros") {
+		if kind := ShadowingCommand(name); kind != "" {
+			return fmt.Errorf("%s shadows the %s '%s' command and is ignored", key, kind, name)
+		}
+	}
+	return CheckConfigValue(key, value)
+}
+
+// yamlErrorLine finds the line number in YAML decoder errors.
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// syntaxErrorLine returns the line a decode error points at, or 0.
+func syntaxErrorLine(raw []byte, err error) int {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return lineAt(raw, syntaxErr.Offset)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return lineAt(raw, typeErr.Offset)
+	}
+	if line := tomlErrorLine(err); line > 0 {
+		return line
+	}
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+// lineAt returns the 1-based line of a byte offset.
+func lineAt(raw []byte, offset int64) int {
+	if offset > int64(len(raw)) {
+		offset = int64(len(raw))
+	}
+	return 1 + bytes.Count(raw[:offset], []byte("\n"))
+}
+
+// configKeyLines maps every dotted (lower case) key of a config file to the
+// line it is defined on. Files that cannot be parsed give an empty map.
+func configKeyLines(path string, raw []byte) map[string]int {
+	lines := map[string]int{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		var doc yamlv3.Node
+		if err := yamlv3.Unmarshal(raw, &doc); err == nil {
+			yamlKeyLines(&doc, "", lines)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		jsonKeyLines(dec, raw, "", lines)
+	case ".toml":
+		tomlKeyLines(raw, lines)
+	}
+	return lines
+}
+
+// yamlKeyLines records the lines of the mapping keys below node.
+func yamlKeyLines(node *yamlv3.Node, prefix string, lines map[string]int) {
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			yamlKeyLines(child, prefix, lines)
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := joinKey(prefix, node.Content[i].Value)
+			lines[key] = node.Content[i].Line
+			yamlKeyLines(node.Content[i+1], key, lines)
+		}
+	}
+}
+
+// jsonKeyLines records the lines of the object keys of the next JSON value
+// read from dec.
+func jsonKeyLines(dec *json.Decoder, raw []byte, prefix string, lines map[string]int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := keyTok.(string)
+			key := joinKey(prefix, name)
+			lines[key] = lineAt(raw, dec.InputOffset())
+			if err := jsonKeyLines(dec, raw, key, lines); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case json.Delim('['):
+		for dec.More() {
+			if err := jsonKeyLines(dec, raw, prefix, lines); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	return nil
+}
+
+// joinKey appends a key segment to a dotted prefix.
+func joinKey(prefix, name string) string {
+	name = strings.ToLower(name)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
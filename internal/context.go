@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultSource is the log backend used when a context does not name one.
+const DefaultSource = "cloudwatch"
+
+// SupportedSources lists the log backends a context may select.
+var SupportedSources = []string{DefaultSource}
+
+// Context bundles everything needed to talk to one environment, similar to a
+// kubectl context. When RoleARN is set, commands assume that role (with
+// ExternalID and an MFA token for MFASerial, if configured); with
+// SSOAccountID and SSORoleName set, credentials come from IAM Identity
+// Center. Contexts live under "contexts.<name>" in the config file and the
+// active one is recorded in "current_context".
+type Context struct {
+	Name           string `mapstructure:"-"`
+	Profile        string `mapstructure:"profile"`
+	Region         string `mapstructure:"region"`
+	RoleARN        string `mapstructure:"role_arn"`
+	ExternalID     string `mapstructure:"external_id"`
+	SessionName    string `mapstructure:"session_name"`
+	MFASerial      string `mapstructure:"mfa_serial"`
+	SSOStartURL    string `mapstructure:"sso_start_url"`
+	SSORegion      string `mapstructure:"sso_region"`
+	SSOAccountID   string `mapstructure:"sso_account_id"`
+	SSORoleName    string `mapstructure:"sso_role_name"`
+	LogGroupPrefix string `mapstructure:"log_group_prefix"`
+	Source         string `mapstructure:"source"`
+}
+
+// Settings returns the non-empty fields of the context keyed by their config
+// names, ready to be written with SetConfigKey.
+func (c Context) Settings() map[string]any {
+	settings := map[string]any{}
+	for key, val := range map[string]string{
+		"profile":          c.Profile,
+		"region":           c.Region,
+		"role_arn":         c.RoleARN,
+		"external_id":      c.ExternalID,
+		"session_name":     c.SessionName,
+		"mfa_serial":       c.MFASerial,
+		"sso_start_url":    c.SSOStartURL,
+		"sso_region":       c.SSORegion,
+		"sso_account_id":   c.SSOAccountID,
+		"sso_role_name":    c.SSORoleName,
+		"log_group_prefix": c.LogGroupPrefix,
+		"source":           c.Source,
+	} {
+		if val != "" {
+			settings[key] = val
+		}
+	}
+	return settings
+}
+
+// Overrides holds the global --context/--profile/--region flags. They take
+// precedence over the current context, which in turn takes precedence over
+// the aws.profile/aws.region config defaults.
+var Overrides struct {
+	Context string
+	Profile string
+	Region  string
+}
+
+// Contexts returns all configured contexts sorted by name.
+func Contexts() ([]Context, error) {
+	raw := map[string]Context{}
+	if err := viper.UnmarshalKey("contexts", &raw); err != nil {
+		return nil, fmt.Errorf("invalid contexts in config: %w", err)
+	}
+
+	contexts := make([]Context, 0, len(raw))
+	for name, ctx := range raw {
+		ctx.Name = name
+		contexts = append(contexts, ctx)
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// GetContext looks up a context by name. Names are case-insensitive because
+// viper lowercases config keys.
+func GetContext(name string) (Context, error) {
+	name = strings.ToLower(name)
+	contexts, err := Contexts()
+	if err != nil {
+		return Context{}, err
+	}
+	for _, ctx := range contexts {
+		if ctx.Name == name {
+			return ctx, nil
+		}
+	}
+	return Context{}, fmt.Errorf("context '%s' not found", name)
+}
+
+// CurrentContextName returns the context selected by --context, falling back
+// to current_context from the config. It is empty when no context is active.
+func CurrentContextName() string {
+	if Overrides.Context != "" {
+		return strings.ToLower(Overrides.Context)
+	}
+	return strings.ToLower(viper.GetString("current_context"))
+}
+
+// ValidateSource reports whether source names a supported log backend.
+func ValidateSource(source string) error {
+	for _, s := range SupportedSources {
+		if s == source {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported source '%s' (supported: %s)", source, strings.Join(SupportedSources, ", "))
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSessionName is used for assumed roles when a context does not set
+// session_name.
+const defaultSessionName = "pcli"
+
+// credentialsExpiryMargin is how long before their expiry cached credentials
+// are considered stale, so a command never starts with credentials that run
+// out mid-flight.
+const credentialsExpiryMargin = 5 * time.Minute
+
+// credentialsMu serialises role assumption so parallel AWS calls (e.g. a
+// fan-out) prompt for the MFA token only once and then share the result.
+var credentialsMu sync.Mutex
+
+// Credentials are temporary AWS credentials, as returned by STS.
+type Credentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// Valid reports whether the credentials are set and not about to expire.
+func (c Credentials) Valid() bool {
+	return c.AccessKeyID != "" && time.Now().Add(credentialsExpiryMargin).Before(c.Expiration)
+}
+
+// Env returns the credentials as AWS CLI environment variables.
+func (c Credentials) Env() []string {
+	return []string{
+		"AWS_ACCESS_KEY_ID=" + c.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + c.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + c.SessionToken,
+	}
+}
+
+// AssumeRole returns temporary credentials for the role of target, reusing
+// cached credentials until they expire. When the context requires MFA the
+// user is prompted for a token, unless target.NoPrompt is set.
+func AssumeRole(target Target) (Credentials, error) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+
+	path, err := credentialsPath(target)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if creds, err := loadCredentials(path); err == nil && creds.Valid() {
+		return creds, nil
+	}
+
+	sessionName := target.SessionName
+	if sessionName == "" {
+		sessionName = defaultSessionName
+	}
+
+	// The role is assumed with the base profile's credentials
+	base := target
+	base.RoleARN = ""
+	args := []string{"sts", "assume-role",
+		"--role-arn", target.RoleARN,
+		"--role-session-name", sessionName,
+		"--output", "json",
+	}
+	if target.ExternalID != "" {
+		args = append(args, "--external-id", target.ExternalID)
+	}
+	if target.MFASerial != "" {
+		token, err := promptMFAToken(target)
+		if err != nil {
+			return Credentials{}, err
+		}
+		args = append(args, "--serial-number", target.MFASerial, "--token-code", token)
+	}
+
+	stsCmd, err := base.Command(args...)
+	if err != nil {
+		return Credentials{}, err
+	}
+	out, err := stsCmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return Credentials{}, fmt.Errorf("assume role %s: %s", target.RoleARN, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return Credentials{}, fmt.Errorf("assume role %s: %w", target.RoleARN, err)
+	}
+
+	var resp struct {
+		Credentials Credentials `json:"Credentials"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Credentials{}, fmt.Errorf("error unmarshalling assume-role response: %w", err)
+	}
+
+	if err := saveCredentials(path, resp.Credentials); err != nil {
+		return Credentials{}, err
+	}
+	return resp.Credentials, nil
+}
+
+// promptMFAToken asks the user for the current MFA code on stderr so it does
+// not mix with command output.
+func promptMFAToken(target Target) (string, error) {
+	if target.NoPrompt {
+		return "", fmt.Errorf("MFA token required for %s; run any pcli command interactively first", target.RoleARN)
+	}
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("MFA token required for %s but stdin is not a terminal", target.RoleARN)
+	}
+
+	fmt.Fprintf(os.Stderr, "🔐 MFA code for %s: ", target.MFASerial)
+	token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("read MFA token: %w", err)
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// credentialsPath returns the cache file for the role assumed by target. The
+// name is a hash of everything that influences the resulting credentials.
+func credentialsPath(target Target) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		target.Profile, target.RoleARN, target.ExternalID, target.SessionName, target.MFASerial,
+		target.SSOStartURL, target.SSOAccountID, target.SSORoleName,
+	}, "\x00")))
+	return filepath.Join(dir, "credentials", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// loadCredentials reads cached credentials from path.
+func loadCredentials(path string) (Credentials, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var creds Credentials
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("unmarshal credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// saveCredentials stores credentials readable by the current user only.
+func saveCredentials(path string, creds Credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create credentials cache: %w", err)
+	}
+	raw, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("marshal credentials: %w", err)
+	}
+	if err := writeFileAtomic(path, raw, 0o600); err != nil {
+		return fmt.Errorf("write credentials cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAWS puts an aws script on PATH answering sts assume-role with
+// credentials that expire at the time given to expire. calls returns how
+// often it ran.
+func fakeAWS(t *testing.T) (expire func(time.Time), calls func() int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake aws CLI is a shell script")
+	}
+	dir := t.TempDir()
+	callsPath, expirationPath := filepath.Join(dir, "calls"), filepath.Join(dir, "expiration")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$*" >> %q
+printf '{"Credentials": {"AccessKeyId": "AKIA%%s", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "%%s"}}' "$$" "$(cat %q)"
+`, callsPath, expirationPath)
+	if err := os.WriteFile(filepath.Join(dir, "aws"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	expire = func(at time.Time) {
+		if err := os.WriteFile(expirationPath, []byte(at.UTC().Format(time.RFC3339)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	calls = func() int {
+		raw, _ := os.ReadFile(callsPath)
+		return strings.Count(string(raw), "\n")
+	}
+	return expire, calls
+}
+
+// TestAssumeRoleCache checks that assumed role credentials are cached
+// readable by the user only, reused until they are about to expire, and
+// kept apart per role.
+func TestAssumeRoleCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	expire, calls := fakeAWS(t)
+	expire(time.Now().Add(time.Hour))
+
+	target := Target{Profile: "base", RoleARN: "arn:aws:iam::111111111111:role/Admin", NoPrompt: true}
+	first, err := AssumeRole(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Valid() || calls() != 1 {
+		t.Fatalf("credentials = %+v after %d calls", first, calls())
+	}
+
+	path, err := credentialsPath(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("credentials file mode = %o, want 600", perm)
+	}
+
+	if again, err := AssumeRole(target); err != nil || again != first || calls() != 1 {
+		t.Errorf("cached credentials not reused: %+v, %v, %d calls", again, err, calls())
+	}
+
+	other := target
+	other.RoleARN = "arn:aws:iam::222222222222:role/Admin"
+	if _, err := AssumeRole(other); err != nil || calls() != 2 {
+		t.Errorf("other role: %v after %d calls, want its own assume-role", err, calls())
+	}
+
+	// Credentials within the expiry margin are assumed again
+	old := Credentials{AccessKeyID: "old", Expiration: time.Now().Add(credentialsExpiryMargin / 2)}
+	if err := saveCredentials(path, old); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := AssumeRole(target)
+	if err != nil || renewed.AccessKeyID == "old" || !renewed.Valid() || calls() != 3 {
+		t.Errorf("expiring credentials not renewed: %+v, %v, %d calls", renewed, err, calls())
+	}
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// durationPart matches one number+unit component of a duration like "1d12h".
+var durationPart = regexp.MustCompile(`(\d+(?:\.\d+)?)(ns|us|µs|ms|s|m|h|d|w)`)
+
+// ParseDuration is like time.ParseDuration but also accepts days ("d") and
+// weeks ("w"), e.g. "7d" or "1w2d", which are common for cache ages.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		loc := durationPart.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			return 0, fmt.Errorf("invalid duration %q (use e.g. 30m, 12h, 7d, 2w)", s)
+		}
+		number, unit := rest[loc[2]:loc[3]], rest[loc[4]:loc[5]]
+		rest = rest[loc[1]:]
+
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			total += time.Duration(n * float64(day))
+		default:
+			d, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			total += d
+		}
+	}
+	return total, nil
+}
+
+// DurationFlag is a flag value parsed with ParseDuration, so flags accept
+// days and weeks like the cache settings do.
+type DurationFlag struct {
+	D *time.Duration
+}
+
+func (f DurationFlag) String() string {
+	// Empty when unset, so help does not print a default of 0s
+	if f.D == nil || *f.D == 0 {
+		return ""
+	}
+	return f.D.String()
+}
+
+func (f DurationFlag) Set(s string) error {
+	d, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*f.D = d
+	return nil
+}
+
+func (f DurationFlag) Type() string {
+	return "duration"
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "1d", want: 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "1w2d", want: 9 * 24 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "30m", want: 30 * time.Minute},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "250ms", want: 250 * time.Millisecond},
+		{in: "", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "1x", wantErr: true},
+		{in: "1d x", wantErr: true},
+		{in: "10", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "-30m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Origin is one target of a fan-out together with the label its log lines
+// are tagged with.
+type Origin struct {
+	Label  string
+	Target Target
+}
+
+// logEntry is a single log event as printed by `aws logs tail`, possibly
+// spanning several lines.
+type logEntry struct {
+	timestamp string
+	label     string
+	lines     []string
+}
+
+// timestampPattern matches the ISO timestamp `aws logs tail` starts every
+// event with.
+var timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\S+`)
+
+// FanOutTargets expands lists of contexts and regions into the origins a
+// fan-out runs against. An empty list keeps the current context or the
+// region it resolves to; when both are given every context is queried in
+// every region.
+func FanOutTargets(contexts, regions []string) ([]Origin, error) {
+	names := contexts
+	if len(names) == 0 {
+		names = []string{CurrentContextName()}
+	}
+
+	var origins []Origin
+	for _, name := range names {
+		target, err := ResolveTarget(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(regions) == 0 {
+			origins = append(origins, Origin{Label: originLabel(target, len(contexts) > 0, false), Target: target})
+			continue
+		}
+		for _, region := range regions {
+			regional := target
+			regional.Region = strings.TrimSpace(region)
+			origins = append(origins, Origin{Label: originLabel(regional, len(contexts) > 0, true), Target: regional})
+		}
+	}
+	return origins, nil
+}
+
+// originLabel names an origin by the dimensions that were fanned out over.
+func originLabel(t Target, byContext, byRegion bool) string {
+	var parts []string
+	if byContext {
+		name := t.Context
+		if name == "" {
+			name = "default"
+		}
+		parts = append(parts, name)
+	}
+	if byRegion {
+		parts = append(parts, t.Region)
+	}
+	return strings.Join(parts, "/")
+}
+
+// GetLogsFanOut tails logGroup on all origins in parallel and tags every
+// line with the label of the origin it came from. With follow, lines are
+// printed as they arrive; otherwise the events of all origins are merged in
+// timestamp order once every tail has finished. A failing origin does not
+// abort the others; its error is returned instead.
+func GetLogsFanOut(origins []Origin, logGroup string, follow bool, since time.Duration) []error {
+	return fanOut(origins, follow, os.Stdout, func(origin Origin, emit func(string)) ([]logEntry, error) {
+		return tailOrigin(origin, logGroup, follow, since, emit)
+	})
+}
+
+// fanOut runs tail on every origin whose credentials resolve and writes the
+// tagged lines to out, as they arrive with follow and else merged in
+// timestamp order.
+func fanOut(origins []Origin, follow bool, out io.Writer, tail func(Origin, func(string)) ([]logEntry, error)) []error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // guards out, errs and entries
+		errs    []error
+		entries []logEntry
+	)
+
+	// Resolve credentials one origin at a time before any tail starts, so
+	// MFA prompts come one after the other instead of racing each other
+	// into the log stream. The tails then reuse the cached credentials.
+	var ready []Origin
+	for _, origin := range origins {
+		if err := origin.Target.EnsureCredentials(); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] %w", origin.Label, err))
+			continue
+		}
+		ready = append(ready, origin)
+	}
+
+	for _, origin := range ready {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			collected, err := tail(origin, func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintf(out, "[%s] %s\n", origin.Label, line)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("[%s] %w", origin.Label, err))
+			}
+			entries = append(entries, collected...)
+		}()
+	}
+	wg.Wait()
+
+	if !follow {
+		// Stable sort keeps the original order of events sharing a timestamp
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].timestamp < entries[j].timestamp
+		})
+		for _, entry := range entries {
+			for _, line := range entry.lines {
+				fmt.Fprintf(out, "[%s] %s\n", entry.label, line)
+			}
+		}
+	}
+
+	return errs
+}
+
+// tailOrigin runs `aws logs tail` for a single origin. With follow every line
+// is handed to emit immediately; otherwise the output is returned as entries
+// for merging.
+func tailOrigin(origin Origin, logGroup string, follow bool, since time.Duration, emit func(string)) ([]logEntry, error) {
+	if err := ValidateSource(origin.Target.Source); err != nil {
+		return nil, err
+	}
+
+	awsCmd, err := origin.Target.Command(tailArgs(logGroup, follow, since)...)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	awsCmd.Stderr = &stderr
+	// Do not wait forever for stderr held open by processes the AWS CLI
+	// left behind once it was killed
+	awsCmd.WaitDelay = time.Second
+
+	stdout, err := awsCmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run aws logs tail: %w", err)
+	}
+	if err := awsCmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run aws logs tail: %w", err)
+	}
+
+	var entries []logEntry
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if follow {
+			emit(line)
+			continue
+		}
+		entries = addLogLine(entries, origin.Label, line)
+	}
+	if err := scanner.Err(); err != nil {
+		// Nothing reads the pipe anymore, so the AWS CLI would block on it
+		// and Wait would never return
+		awsCmd.Process.Kill()
+		awsCmd.Wait()
+		return entries, fmt.Errorf("read aws logs tail output: %w", err)
+	}
+
+	if err := awsCmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return entries, fmt.Errorf("aws logs tail failed: %s", msg)
+		}
+		return entries, fmt.Errorf("failed to run aws logs tail: %w", err)
+	}
+	return entries, nil
+}
+
+// addLogLine adds a line of `aws logs tail` output to entries. Lines without
+// a timestamp continue the previous event.
+func addLogLine(entries []logEntry, label, line string) []logEntry {
+	if ts := timestampPattern.FindString(line); ts != "" || len(entries) == 0 {
+		entries = append(entries, logEntry{timestamp: ts, label: label})
+	}
+	last := &entries[len(entries)-1]
+	last.lines = append(last.lines, line)
+	return entries
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// stubTail returns a tail that produces the given output per origin label
+// and fails for labels in failing, after producing their output.
+func stubTail(output map[string][]string, failing map[string]bool) func(Origin, func(string)) ([]logEntry, error) {
+	return func(origin Origin, emit func(string)) ([]logEntry, error) {
+		var entries []logEntry
+		for _, line := range output[origin.Label] {
+			if emit != nil {
+				emit(line)
+			}
+			entries = addLogLine(entries, origin.Label, line)
+		}
+		if failing[origin.Label] {
+			return entries, errors.New("aws logs tail failed: AccessDenied")
+		}
+		return entries, nil
+	}
+}
+
+// TestFanOutMerge checks that events of all origins are merged in timestamp
+// order with their continuation lines, tagged with their origin, and that a
+// failing origin is reported without losing the others.
+func TestFanOutMerge(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	origins := []Origin{
+		{Label: "prod/us-east-1"},
+		{Label: "prod/eu-west-1"},
+		{Label: "broken"},
+		// No SSO login, so its tail never starts
+		{Label: "sso", Target: Target{SSOStartURL: "https://example.awsapps.com/start", SSORegion: "eu-west-1", SSOAccountID: "1", SSORoleName: "r"}},
+	}
+	output := map[string][]string{
+		"prod/us-east-1": {
+			"2025-01-01T10:00:01 a first",
+			"2025-01-01T10:00:03 a third",
+			"  at handler.js:12",
+		},
+		"prod/eu-west-1": {
+			"2025-01-01T10:00:02 b second",
+			"2025-01-01T10:00:04 b fourth",
+		},
+		"broken": {"2025-01-01T10:00:00 c partial"},
+		"sso":    {"2025-01-01T09:00:00 never"},
+	}
+
+	var out bytes.Buffer
+	tail := stubTail(output, map[string]bool{"broken": true})
+	errs := fanOut(origins, false, &out, func(origin Origin, emit func(string)) ([]logEntry, error) {
+		return tail(origin, nil)
+	})
+
+	want := strings.Join([]string{
+		"[broken] 2025-01-01T10:00:00 c partial",
+		"[prod/us-east-1] 2025-01-01T10:00:01 a first",
+		"[prod/eu-west-1] 2025-01-01T10:00:02 b second",
+		"[prod/us-east-1] 2025-01-01T10:00:03 a third",
+		"[prod/us-east-1]   at handler.js:12",
+		"[prod/eu-west-1] 2025-01-01T10:00:04 b fourth",
+	}, "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	sort.Strings(messages)
+	if len(messages) != 2 || !strings.HasPrefix(messages[0], "[broken] aws logs tail failed") || !strings.HasPrefix(messages[1], "[sso] ") {
+		t.Errorf("errors = %q, want broken and sso", messages)
+	}
+}
+
+// TestFanOutFollow checks that followed lines of parallel origins are
+// written whole, each tagged with its origin.
+func TestFanOutFollow(t *testing.T) {
+	output := map[string][]string{}
+	var origins []Origin
+	for i := 0; i < 4; i++ {
+		label := fmt.Sprintf("region-%d", i)
+		origins = append(origins, Origin{Label: label})
+		for j := 0; j < 200; j++ {
+			output[label] = append(output[label], fmt.Sprintf("line %d of %s", j, label))
+		}
+	}
+
+	var out bytes.Buffer
+	if errs := fanOut(origins, true, &out, stubTail(output, nil)); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("got %d lines, want 800", len(lines))
+	}
+	next := map[string]int{}
+	for _, line := range lines {
+		var label string
+		var n int
+		if _, err := fmt.Sscanf(line, "[%s line %d of", &label, &n); err != nil {
+			t.Fatalf("malformed line %q", line)
+		}
+		label = strings.TrimSuffix(label, "]")
+		if n != next[label] || !strings.HasSuffix(line, " of "+label) {
+			t.Fatalf("line %q out of order or mislabelled", line)
+		}
+		next[label]++
+	}
+}
+
+func TestOriginLabel(t *testing.T) {
+	target := Target{Context: "prod", Region: "eu-west-1"}
+	tests := []struct {
+		target              Target
+		byContext, byRegion bool
+		want                string
+	}{
+		{target, true, false, "prod"},
+		{target, false, true, "eu-west-1"},
+		{target, true, true, "prod/eu-west-1"},
+		{Target{Region: "us-east-1"}, true, true, "default/us-east-1"},
+	}
+	for _, tt := range tests {
+		if got := originLabel(tt.target, tt.byContext, tt.byRegion); got != tt.want {
+			t.Errorf("originLabel(%+v, %v, %v) = %q, want %q", tt.target, tt.byContext, tt.byRegion, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "go.yaml.in/yaml/v3"
+)
+
+// OutputFormats lists the formats accepted by --output.
+var OutputFormats = []string{"json", "yaml", "plain"}
+
+// FormatValue renders a decoded value as indented JSON, YAML or plain lines
+// (one list element or "key=value" pair per line), ready for scripting.
+func FormatValue(value any, format string) (string, error) {
+	switch format {
+	case "json", "":
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal json: %w", err)
+		}
+		return string(out), nil
+	case "yaml":
+		// Indented like the YAML config files pcli writes
+		var out bytes.Buffer
+		enc := yaml.NewEncoder(&out)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return "", fmt.Errorf("marshal yaml: %w", err)
+		}
+		enc.Close()
+		return strings.TrimSuffix(out.String(), "\n"), nil
+	case "plain":
+		return formatPlain(value), nil
+	default:
+		return "", fmt.Errorf("unsupported output format '%s' (supported: %s)", format, strings.Join(OutputFormats, ", "))
+	}
+}
+
+// formatPlain renders lists line by line and objects as sorted key=value
+// lines; nested structures fall back to compact JSON.
+func formatPlain(value any) string {
+	if list, ok := value.([]any); ok {
+		lines := make([]string, len(list))
+		for i, elem := range list {
+			lines[i] = plainScalar(elem)
+		}
+		return strings.Join(lines, "\n")
+	}
+	if fields, ok := asStringMap(value); ok {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		lines := make([]string, len(keys))
+		for i, k := range keys {
+			lines[i] = k + "=" + plainScalar(fields[k])
+		}
+		return strings.Join(lines, "\n")
+	}
+	return plainScalar(value)
+}
+
+// plainScalar renders a single value on one line.
+func plainScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case []any, map[string]any, map[any]any:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(out)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,274 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// PluginPrefix is the name prefix of plugin executables: pcli-deploy
+// provides the "deploy" command.
+const PluginPrefix = "pcli-"
+
+// Flags of the plugin protocol, which a plugin answers instead of running.
+const (
+	PluginDescribeFlag = "--pcli-describe"
+	PluginCompleteFlag = "--pcli-complete"
+)
+
+// pluginTimeout bounds protocol calls, so a broken plugin cannot hang help
+// or shell completion.
+const pluginTimeout = 3 * time.Second
+
+// Plugin is an external command provided by a pcli-<name> executable.
+type Plugin struct {
+	Name string
+	Path string
+	// Installed plugins live in the plugin directory, the others on PATH
+	Installed bool
+}
+
+// PluginDescription is what a plugin prints as JSON when run with
+// --pcli-describe. Every field is optional.
+type PluginDescription struct {
+	Short   string `json:"short,omitempty"`
+	Long    string `json:"long,omitempty"`
+	Usage   string `json:"usage,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Args are completion candidates for the first argument
+	Args []string `json:"args,omitempty"`
+	// Flags are completion candidates for flags, like --dry-run
+	Flags []string `json:"flags,omitempty"`
+	// Complete is set by plugins that answer --pcli-complete <args...>
+	// with one completion candidate per line
+	Complete bool `json:"complete,omitempty"`
+}
+
+// PluginDir returns the directory pcli installs plugins into,
+// ~/.pcli/plugins.
+func PluginDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".pcli", "plugins"), nil
+}
+
+// DiscoverPlugins finds the pcli-* executables in the plugin directory and
+// on PATH, sorted by name. When several have the same name, the plugin
+// directory wins, then the first PATH entry.
+func DiscoverPlugins() []Plugin {
+	found := map[string]Plugin{}
+	add := func(dir string, installed bool) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || found[name].Path != "" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if isExecutable(path) {
+				found[name] = Plugin{Name: name, Path: path, Installed: installed}
+			}
+		}
+	}
+
+	if dir, err := PluginDir(); err == nil {
+		add(dir, true)
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			add(dir, false)
+		}
+	}
+
+	plugins := make([]Plugin, 0, len(found))
+	for _, p := range found {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the command name of a plugin file, without prefix and,
+// on Windows, without extension.
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, PluginPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, PluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isExecutable reports whether path is a regular file the user can run.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// Describe asks the plugin for its help and completion data.
+func (p Plugin) Describe() (PluginDescription, error) {
+	out, err := p.protocol(PluginDescribeFlag)
+	if err != nil {
+		return PluginDescription{}, err
+	}
+	var desc PluginDescription
+	if err := json.Unmarshal(out, &desc); err != nil {
+		return PluginDescription{}, fmt.Errorf("%s %s: invalid JSON: %w", p.Path, PluginDescribeFlag, err)
+	}
+	return desc, nil
+}
+
+// Completions asks a plugin that supports it for completion candidates of
+// the word being typed, given the arguments before it.
+func (p Plugin) Completions(args []string, toComplete string) ([]string, error) {
+	out, err := p.protocol(append(append([]string{PluginCompleteFlag}, args...), toComplete)...)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			candidates = append(candidates, line)
+		}
+	}
+	return candidates, scanner.Err()
+}
+
+// protocol runs the plugin with protocol arguments and returns its output.
+func (p Plugin) protocol(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, p.Path, args...).Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s %s: timed out after %s", p.Path, args[0], pluginTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", p.Path, args[0], err)
+	}
+	return out, nil
+}
+
+// PluginEnv returns the environment a plugin runs with: pcli's own plus
+// PCLI_CONTEXT, PCLI_PROFILE and PCLI_REGION for the target, PCLI_CONFIG
+// and PCLI_BIN, and the AWS variables that make the AWS CLI and SDKs use
+// the same target, including temporary credentials of roles and SSO.
+func PluginEnv(t Target) ([]string, error) {
+	env := append(os.Environ(),
+		"PCLI_CONTEXT="+t.Context,
+		"PCLI_PROFILE="+t.Profile,
+		"PCLI_REGION="+t.Region,
+	)
+	if path := viper.ConfigFileUsed(); path != "" {
+		env = append(env, "PCLI_CONFIG="+path)
+	}
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "PCLI_BIN="+exe)
+	}
+	if t.Region != "" {
+		env = append(env, "AWS_REGION="+t.Region, "AWS_DEFAULT_REGION="+t.Region)
+	}
+
+	creds, ok, err := t.credentials()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return append(env, creds.Env()...), nil
+	}
+	if t.Profile != "" {
+		env = append(env, "AWS_PROFILE="+t.Profile)
+	}
+	return env, nil
+}
+
+// InstallPlugin copies a local executable into the plugin directory as
+// pcli-<name>, where name defaults to the file name without the pcli- prefix.
+// An installed plugin of the same name is only replaced with force.
+func InstallPlugin(src, name string, force bool) (Plugin, error) {
+	if name == "" {
+		name = strings.TrimPrefix(filepath.Base(src), PluginPrefix)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	if name == "" || strings.ContainsAny(name, `/\ `) {
+		return Plugin{}, fmt.Errorf("invalid plugin name '%s'", name)
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("read plugin: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return Plugin{}, fmt.Errorf("%s is not a file", src)
+	}
+
+	dir, err := PluginDir()
+	if err != nil {
+		return Plugin{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Plugin{}, fmt.Errorf("create plugin directory: %w", err)
+	}
+
+	file := PluginPrefix + name
+	if runtime.GOOS == "windows" {
+		file += filepath.Ext(src)
+	}
+	dst := filepath.Join(dir, file)
+	if _, err := os.Stat(dst); err == nil && !force {
+		return Plugin{}, fmt.Errorf("plugin '%s' is already installed (use --force to replace it)", name)
+	}
+
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("read plugin: %w", err)
+	}
+	if err := writeFileAtomic(dst, raw, 0o755); err != nil {
+		return Plugin{}, fmt.Errorf("install plugin: %w", err)
+	}
+	return Plugin{Name: name, Path: dst, Installed: true}, nil
+}
+
+// RemovePlugin deletes an installed plugin from the plugin directory.
+// Plugins found on PATH belong to the user and are never deleted.
+func RemovePlugin(name string) (Plugin, error) {
+	for _, p := range DiscoverPlugins() {
+		if p.Name != name {
+			continue
+		}
+		if !p.Installed {
+			return Plugin{}, fmt.Errorf("plugin '%s' was not installed by pcli (%s); remove it from PATH yourself", name, p.Path)
+		}
+		if err := os.Remove(p.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return Plugin{}, fmt.Errorf("remove plugin: %w", err)
+		}
+		return p, nil
+	}
+	return Plugin{}, fmt.Errorf("plugin '%s' not found", name)
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes an executable shell script named pcli-<name> to dir.
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, PluginPrefix+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestDiscoverPlugins checks that installed plugins win over those on PATH,
+// earlier PATH entries over later ones, and that files that cannot be run
+// are not plugins.
+func TestDiscoverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	home, first, second := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+	installed := filepath.Join(home, ".pcli", "plugins")
+	if err := os.MkdirAll(installed, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := writePlugin(t, installed, "deploy", "")
+	writePlugin(t, first, "deploy", "")
+	lint := writePlugin(t, first, "lint", "")
+	writePlugin(t, second, "lint", "")
+	if err := os.WriteFile(filepath.Join(second, "pcli-notes"), []byte("text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := DiscoverPlugins()
+	if len(plugins) != 2 {
+		t.Fatalf("plugins = %+v, want deploy and lint", plugins)
+	}
+	if p := plugins[0]; p.Name != "deploy" || p.Path != deploy || !p.Installed {
+		t.Errorf("deploy = %+v, want the installed one", p)
+	}
+	if p := plugins[1]; p.Name != "lint" || p.Path != lint || p.Installed {
+		t.Errorf("lint = %+v, want the first on PATH", p)
+	}
+}
+
+// TestPluginProtocol checks that descriptions and completion candidates are
+// read from plugins, and that protocol failures are reported.
+func TestPluginProtocol(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	deploy := Plugin{Name: "deploy", Path: writePlugin(t, dir, "deploy", `
+case "$1" in
+--pcli-describe) echo '{"short": "Deploy a service", "args": ["api"], "complete": true}' ;;
+--pcli-complete) shift; echo "args:$*"; echo; echo "  worker  " ;;
+esac
+`)}
+	broken := Plugin{Name: "broken", Path: writePlugin(t, dir, "broken", "echo not json\n")}
+	failing := Plugin{Name: "failing", Path: writePlugin(t, dir, "failing", "exit 3\n")}
+
+	desc, err := deploy.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Short != "Deploy a service" || !desc.Complete || strings.Join(desc.Args, ",") != "api" {
+		t.Errorf("description = %+v", desc)
+	}
+
+	candidates, err := deploy.Completions([]string{"api", "--dry-run"}, "wo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(candidates, "|"); got != "args:api --dry-run wo|worker" {
+		t.Errorf("candidates = %q", got)
+	}
+
+	if _, err := broken.Describe(); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("broken description error = %v", err)
+	}
+	if _, err := failing.Completions(nil, ""); err == nil || !strings.Contains(err.Error(), PluginCompleteFlag) {
+		t.Errorf("failing completion error = %v", err)
+	}
+}
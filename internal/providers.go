@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// CacheProvider fetches one kind of cached data, e.g. the log groups of a
+// target. Subsystems register their providers from an init function so that
+// `pcli cache refresh` can discover them.
+type CacheProvider struct {
+	// Name is the cache key the provider's data is stored under
+	Name string
+	// Description is shown by `pcli cache providers`
+	Description string
+	// TTL is how long the data stays fresh; cache_ttl.<name> overrides it
+	TTL time.Duration
+	// DependsOn names providers that must be refreshed first
+	DependsOn []string
+	// Schema is the version of the shape of the fetched data, 1 when unset.
+	// Bump it whenever the shape changes and add a migration for the
+	// previous version.
+	Schema int
+	// Migrations upgrade cached data of schema version n (the key) to n+1.
+	// Cached data without a migration path is discarded and fetched again.
+	Migrations map[int]CacheMigration
+	// Fetch retrieves fresh data for target; it must give up once ctx is done
+	Fetch func(ctx context.Context, target Target) (any, error)
+}
+
+var cacheProviders = map[string]CacheProvider{}
+
+// RegisterCacheProvider adds a provider to the registry. It panics on
+// duplicate or incomplete registrations, which are programming errors.
+func RegisterCacheProvider(p CacheProvider) {
+	if p.Name == "" || p.Fetch == nil {
+		panic("cache provider needs a name and a fetch function")
+	}
+	if _, exists := cacheProviders[p.Name]; exists {
+		panic(fmt.Sprintf("cache provider %q registered twice", p.Name))
+	}
+	cacheProviders[p.Name] = p
+}
+
+// CacheProviders returns all registered providers sorted by name.
+func CacheProviders() []CacheProvider {
+	providers := make([]CacheProvider, 0, len(cacheProviders))
+	for _, p := range cacheProviders {
+		providers = append(providers, p)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// CacheProviderNames returns the names of all registered providers.
+func CacheProviderNames() []string {
+	var names []string
+	for _, p := range CacheProviders() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// ResolveCacheProviders returns the named providers plus everything they
+// depend on, ordered so that dependencies come first. No names selects all
+// providers.
+func ResolveCacheProviders(names []string) ([]CacheProvider, error) {
+	if len(names) == 0 {
+		names = CacheProviderNames()
+	}
+
+	var (
+		ordered []CacheProvider
+		state   = map[string]int{} // 1 = visiting, 2 = done
+		visit   func(name string, path []string) error
+	)
+	visit = func(name string, path []string) error {
+		p, ok := cacheProviders[name]
+		if !ok {
+			return fmt.Errorf("unknown cache provider '%s' (available: %s)", name, strings.Join(CacheProviderNames(), ", "))
+		}
+		// A copy, so sibling dependencies never share a backing array
+		path = append(path[:len(path):len(path)], name)
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("cache provider dependency cycle: %s", strings.Join(path, " -> "))
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+		for _, dep := range p.DependsOn {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		ordered = append(ordered, p)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Defaults for RefreshOptions, overridable with cache_refresh.concurrency and
+// cache_refresh.timeout in the config.
+const (
+	DefaultRefreshConcurrency = 4
+	DefaultRefreshTimeout     = 2 * time.Minute
+)
+
+// RefreshOptions controls how RefreshCacheProviders runs providers.
+type RefreshOptions struct {
+	// Concurrency bounds how many providers fetch at the same time
+	Concurrency int
+	// Timeout limits the fetch of each provider
+	Timeout time.Duration
+}
+
+// RefreshOptionsFromConfig returns the refresh options configured under
+// cache_refresh, falling back to the defaults.
+func RefreshOptionsFromConfig() RefreshOptions {
+	opts := RefreshOptions{
+		Concurrency: viper.GetInt("cache_refresh.concurrency"),
+		Timeout:     getDuration("cache_refresh.timeout"),
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultRefreshConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultRefreshTimeout
+	}
+	return opts
+}
+
+// RefreshState is the progress of a single provider during a refresh.
+type RefreshState int
+
+const (
+	RefreshPending RefreshState = iota
+	RefreshRunning
+	RefreshDone
+	RefreshFailed
+	RefreshSkipped
+)
+
+// RefreshResult reports the progress and outcome of one provider.
+type RefreshResult struct {
+	Provider string
+	State    RefreshState
+	Started  time.Time
+	Duration time.Duration
+	// Items is the number of cached items, or -1 when the data is not a
+	// collection
+	Items int
+	Err   error
+}
+
+// RefreshCacheProviders refreshes providers for target concurrently, at most
+// opts.Concurrency at a time and each limited to opts.Timeout. A provider
+// starts once all its dependencies succeeded and is skipped if one of them
+// failed. onUpdate is called (serially) on every state change; the final
+// results are returned in the order of providers.
+func RefreshCacheProviders(ctx context.Context, target Target, providers []CacheProvider, opts RefreshOptions, onUpdate func(RefreshResult)) []RefreshResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	var (
+		mu      sync.Mutex // guards results and serialises onUpdate
+		results = make([]RefreshResult, len(providers))
+		done    = map[string]chan struct{}{}
+		index   = map[string]int{}
+		slots   = make(chan struct{}, opts.Concurrency)
+		wg      sync.WaitGroup
+	)
+
+	update := func(i int, change func(r *RefreshResult)) {
+		mu.Lock()
+		defer mu.Unlock()
+		change(&results[i])
+		if onUpdate != nil {
+			onUpdate(results[i])
+		}
+	}
+	stateOf := func(name string) RefreshState {
+		mu.Lock()
+		defer mu.Unlock()
+		return results[index[name]].State
+	}
+
+	for i, p := range providers {
+		results[i] = RefreshResult{Provider: p.Name, Items: -1}
+		done[p.Name] = make(chan struct{})
+		index[p.Name] = i
+	}
+
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[p.Name])
+
+			// Providers are resolved with their dependencies, so every
+			// dependency has a done channel
+			for _, dep := range p.DependsOn {
+				<-done[dep]
+				if stateOf(dep) != RefreshDone {
+					update(i, func(r *RefreshResult) {
+						r.State = RefreshSkipped
+						r.Err = fmt.Errorf("dependency '%s' failed", dep)
+					})
+					return
+				}
+			}
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			started := time.Now()
+			update(i, func(r *RefreshResult) {
+				r.State = RefreshRunning
+				r.Started = started
+			})
+
+			fetchCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+			items, err := refreshCacheProvider(fetchCtx, target, p)
+			if errors.Is(fetchCtx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("timed out after %s", opts.Timeout)
+			}
+
+			update(i, func(r *RefreshResult) {
+				r.Duration = time.Since(started)
+				r.Items = items
+				r.Err = err
+				if err != nil {
+					r.State = RefreshFailed
+				} else {
+					r.State = RefreshDone
+				}
+			})
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// refreshCacheProvider fetches fresh data from p, stores it in the cache
+// namespace of target and returns the number of items fetched.
+func refreshCacheProvider(ctx context.Context, target Target, p CacheProvider) (int, error) {
+	value, err := p.Fetch(ctx, target)
+	if err != nil {
+		return -1, err
+	}
+	if err := SetCacheEntry(target, p.Name, value); err != nil {
+		return -1, err
+	}
+	return ItemCount(value), nil
+}
+
+// ItemCount returns the number of elements of a slice or map value, or -1
+// for anything else.
+func ItemCount(v any) int {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withCacheProviders replaces the provider registry for the duration of a
+// test.
+func withCacheProviders(t *testing.T, providers ...CacheProvider) {
+	saved := cacheProviders
+	t.Cleanup(func() { cacheProviders = saved })
+	cacheProviders = map[string]CacheProvider{}
+	for _, p := range providers {
+		RegisterCacheProvider(p)
+	}
+}
+
+// fakeProvider returns a provider fetching a fixed list.
+func fakeProvider(name string, deps ...string) CacheProvider {
+	return CacheProvider{
+		Name:      name,
+		DependsOn: deps,
+		Fetch: func(ctx context.Context, target Target) (any, error) {
+			return []string{name}, nil
+		},
+	}
+}
+
+func TestResolveCacheProviders(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []CacheProvider
+		resolve   []string
+		want      string
+		wantErr   string
+	}{
+		{
+			name:      "all providers by name",
+			providers: []CacheProvider{fakeProvider("b"), fakeProvider("a"), fakeProvider("c")},
+			want:      "a b c",
+		},
+		{
+			name:      "dependencies first",
+			providers: []CacheProvider{fakeProvider("streams", "groups"), fakeProvider("groups", "accounts"), fakeProvider("accounts")},
+			resolve:   []string{"streams"},
+			want:      "accounts groups streams",
+		},
+		{
+			name:      "shared dependency once",
+			providers: []CacheProvider{fakeProvider("a", "base"), fakeProvider("b", "base"), fakeProvider("base")},
+			resolve:   []string{"b", "a"},
+			want:      "base b a",
+		},
+		{
+			name:      "cycle",
+			providers: []CacheProvider{fakeProvider("a", "b"), fakeProvider("b", "c"), fakeProvider("c", "a")},
+			resolve:   []string{"a"},
+			wantErr:   "cycle: a -> b -> c -> a",
+		},
+		{
+			name: "cycle below siblings",
+			providers: []CacheProvider{
+				fakeProvider("a", "b", "c"), fakeProvider("b", "d"), fakeProvider("c", "d", "e"),
+				fakeProvider("d"), fakeProvider("e", "f"), fakeProvider("f", "g"), fakeProvider("g", "c"),
+			},
+			resolve: []string{"a"},
+			wantErr: "cycle: a -> c -> e -> f -> g -> c",
+		},
+		{
+			name:      "self dependency",
+			providers: []CacheProvider{fakeProvider("a", "a")},
+			wantErr:   "cycle: a -> a",
+		},
+		{
+			name:      "unknown dependency",
+			providers: []CacheProvider{fakeProvider("a", "missing")},
+			wantErr:   "unknown cache provider 'missing'",
+		},
+		{
+			name:      "unknown provider",
+			providers: []CacheProvider{fakeProvider("a")},
+			resolve:   []string{"nope"},
+			wantErr:   "unknown cache provider 'nope' (available: a)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCacheProviders(t, tt.providers...)
+			resolved, err := ResolveCacheProviders(tt.resolve)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, p := range resolved {
+				names = append(names, p.Name)
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterCacheProviderPanics(t *testing.T) {
+	tests := map[string]CacheProvider{
+		"duplicate": fakeProvider("a"),
+		"no name":   {Fetch: fakeProvider("x").Fetch},
+		"no fetch":  {Name: "b"},
+	}
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+			withCacheProviders(t, fakeProvider("a"))
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %+v did not panic", p)
+				}
+			}()
+			RegisterCacheProvider(p)
+		})
+	}
+}
+
+func TestRefreshCacheProvidersConcurrency(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var running, peak atomic.Int32
+	var providers []CacheProvider
+	for i := 0; i < 8; i++ {
+		providers = append(providers, CacheProvider{
+			Name: fmt.Sprintf("p%d", i),
+			Fetch: func(ctx context.Context, target Target) (any, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					old := peak.Load()
+					if n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				return []string{}, nil
+			},
+		})
+	}
+
+	results := RefreshCacheProviders(context.Background(), Target{}, providers,
+		RefreshOptions{Concurrency: 3, Timeout: time.Minute}, nil)
+	for _, r := range results {
+		if r.State != RefreshDone {
+			t.Errorf("%s: state %d, err %v", r.Provider, r.State, r.Err)
+		}
+	}
+	if got := peak.Load(); got > 3 || got < 2 {
+		t.Errorf("peak parallelism = %d, want 2..3", got)
+	}
+}
+
+func TestRefreshCacheProvidersTimeoutAndSkip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	slow := CacheProvider{
+		Name: "slow",
+		Fetch: func(ctx context.Context, target Target) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	broken := CacheProvider{
+		Name: "broken",
+		Fetch: func(ctx context.Context, target Target) (any, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	providers := []CacheProvider{
+		slow,
+		broken,
+		fakeProvider("child", "broken"),
+		fakeProvider("grandchild", "child"),
+		fakeProvider("after_slow", "slow"),
+		fakeProvider("independent"),
+	}
+
+	results := RefreshCacheProviders(context.Background(), Target{}, providers,
+		RefreshOptions{Concurrency: 2, Timeout: 50 * time.Millisecond}, nil)
+
+	want := map[string]RefreshState{
+		"slow":        RefreshFailed,
+		"broken":      RefreshFailed,
+		"child":       RefreshSkipped,
+		"grandchild":  RefreshSkipped,
+		"after_slow":  RefreshSkipped,
+		"independent": RefreshDone,
+	}
+	for _, r := range results {
+		if r.State != want[r.Provider] {
+			t.Errorf("%s: state %d, want %d (err %v)", r.Provider, r.State, want[r.Provider], r.Err)
+		}
+	}
+	if err := results[0].Err; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow provider error = %v, want a timeout", err)
+	}
+	if err := results[2].Err; err == nil || !strings.Contains(err.Error(), "'broken'") {
+		t.Errorf("child error = %v, want the failed dependency", err)
+	}
+}
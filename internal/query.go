@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SplitQuery splits a cache query like "log_groups[0]" into the cache key
+// and the remaining path ("[0]").
+func SplitQuery(query string) (key, path string) {
+	if i := strings.IndexAny(query, ".["); i >= 0 {
+		return query[:i], query[i:]
+	}
+	return query, ""
+}
+
+// QueryValue evaluates a JSONPath-style path against a decoded JSON value.
+// Supported segments:
+//
+//	.name         field of an object
+//	[n]           element n of a list (negative counts from the end)
+//	[a:b]         slice of a list
+//	[*]           all elements
+//	[?text]       elements containing text (case-insensitive)
+//	[?field=val]  object elements whose field equals val
+//	[?field~val]  object elements whose field contains val
+//
+// Segments after [*], a slice or a filter apply to every selected element.
+func QueryValue(value any, path string) (any, error) {
+	segments, err := parseQueryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return applyQuery(value, segments)
+}
+
+// querySegment is a single step of a query path.
+type querySegment struct {
+	field  string // .name
+	index  string // contents of [...]
+	isList bool   // whether the segment was written in brackets
+}
+
+// parseQueryPath splits a path into segments.
+func parseQueryPath(path string) ([]querySegment, error) {
+	var segments []querySegment
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty field name in query")
+			}
+			segments = append(segments, querySegment{field: path[:end]})
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ']' in query")
+			}
+			segments = append(segments, querySegment{index: path[1:end], isList: true})
+			path = path[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected '%c' in query", path[0])
+		}
+	}
+	return segments, nil
+}
+
+// applyQuery evaluates segments against value.
+func applyQuery(value any, segments []querySegment) (any, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	seg, rest := segments[0], segments[1:]
+
+	if !seg.isList {
+		fields, ok := asStringMap(value)
+		if !ok {
+			return nil, fmt.Errorf("cannot select field '%s' of a %s", seg.field, typeName(value))
+		}
+		child, ok := fields[seg.field]
+		if !ok {
+			return nil, fmt.Errorf("field '%s' not found", seg.field)
+		}
+		return applyQuery(child, rest)
+	}
+
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot index [%s] into a %s", seg.index, typeName(value))
+	}
+
+	// A single index selects one element; everything else selects a list
+	if n, err := strconv.Atoi(seg.index); err == nil {
+		if n < 0 {
+			n += len(list)
+		}
+		if n < 0 || n >= len(list) {
+			return nil, fmt.Errorf("index %s out of range (%d items)", seg.index, len(list))
+		}
+		return applyQuery(list[n], rest)
+	}
+
+	selected, err := selectElements(list, seg.index)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]any, 0, len(selected))
+	for _, elem := range selected {
+		result, err := applyQuery(elem, rest)
+		if err != nil {
+			// Elements the rest of the path does not apply to are dropped
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// selectElements applies a wildcard, slice or filter to list.
+func selectElements(list []any, index string) ([]any, error) {
+	switch {
+	case index == "*":
+		return list, nil
+	case strings.HasPrefix(index, "?"):
+		return filterElements(list, index[1:]), nil
+	case strings.Contains(index, ":"):
+		from, to, _ := strings.Cut(index, ":")
+		start, end := 0, len(list)
+		var err error
+		if from != "" {
+			if start, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid slice [%s]", index)
+			}
+		}
+		if to != "" {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid slice [%s]", index)
+			}
+		}
+		if start < 0 {
+			start += len(list)
+		}
+		if end < 0 {
+			end += len(list)
+		}
+		start = max(0, min(start, len(list)))
+		end = max(start, min(end, len(list)))
+		return list[start:end], nil
+	default:
+		return nil, fmt.Errorf("invalid index [%s]", index)
+	}
+}
+
+// filterElements keeps the elements of list matching a [?...] filter.
+func filterElements(list []any, filter string) []any {
+	field, want, op := "", filter, byte(0)
+	if i := strings.IndexAny(filter, "=~"); i > 0 {
+		field, want, op = filter[:i], filter[i+1:], filter[i]
+	}
+
+	var matched []any
+	for _, elem := range list {
+		subject := elem
+		if field != "" {
+			fields, ok := asStringMap(elem)
+			if !ok {
+				continue
+			}
+			if subject, ok = fields[field]; !ok {
+				continue
+			}
+		}
+
+		text := fmt.Sprint(subject)
+		match := false
+		switch op {
+		case '=':
+			match = text == want
+		default:
+			match = strings.Contains(strings.ToLower(text), strings.ToLower(want))
+		}
+		if match {
+			matched = append(matched, elem)
+		}
+	}
+	return matched
+}
+
+// typeName describes a decoded JSON value for error messages.
+func typeName(v any) string {
+	switch v.(type) {
+	case []any:
+		return "list"
+	case map[string]any, map[any]any:
+		return "object"
+	case string:
+		return "string"
+	case float64, int:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryValue(t *testing.T) {
+	value := []any{
+		map[string]any{"name": "/prod/api", "retention": 30.0},
+		map[string]any{"name": "/prod/worker", "retention": 7.0},
+		map[string]any{"name": "/dev/api", "retention": 7.0},
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"", value},
+		{"[0].name", "/prod/api"},
+		{"[-1].name", "/dev/api"},
+		{"[1:].name", []any{"/prod/worker", "/dev/api"}},
+		{"[*].retention", []any{30.0, 7.0, 7.0}},
+		{"[?name~prod].name", []any{"/prod/api", "/prod/worker"}},
+		{"[?retention=7].name", []any{"/prod/worker", "/dev/api"}},
+		{"[?API].name", []any{"/prod/api", "/dev/api"}},
+	}
+	for _, tt := range tests {
+		got, err := QueryValue(value, tt.path)
+		if err != nil {
+			t.Errorf("QueryValue(%q) error: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryValue(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"[3]", ".name", "[0].missing", "[0", "[x]"} {
+		if _, err := QueryValue(value, path); err == nil {
+			t.Errorf("QueryValue(%q) succeeded, want error", path)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"log_groups", "log_streams", "queries"}
+
+	if got := Suggest("log-groups", candidates); len(got) == 0 || got[0] != "log_groups" {
+		t.Errorf("Suggest(log-groups) = %v, want log_groups first", got)
+	}
+	if got := Suggest("quries", candidates); len(got) == 0 || got[0] != "queries" {
+		t.Errorf("Suggest(quries) = %v, want queries first", got)
+	}
+	if got := Suggest("xyz", candidates); len(got) != 0 {
+		t.Errorf("Suggest(xyz) = %v, want no suggestions", got)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	value := map[string]any{
+		"groups": []any{map[string]any{"name": "/prod/api", "retention": 30.0}},
+		"region": "eu-west-1",
+	}
+	tests := map[string]string{
+		"json":  "{\n  \"groups\": [\n    {\n      \"name\": \"/prod/api\",\n      \"retention\": 30\n    }\n  ],\n  \"region\": \"eu-west-1\"\n}",
+		"yaml":  "groups:\n  - name: /prod/api\n    retention: 30\nregion: eu-west-1",
+		"plain": "groups=[{\"name\":\"/prod/api\",\"retention\":30}]\nregion=eu-west-1",
+	}
+	for format, want := range tests {
+		got, err := FormatValue(value, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if got != want {
+			t.Errorf("%s output =\n%s\nwant\n%s", format, got, want)
+		}
+	}
+	if _, err := FormatValue(value, "xml"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SeedSchemaVersion is the version of the cache export envelope. Imports of
+// newer versions are rejected.
+const SeedSchemaVersion = 1
+
+// SeedSource describes where exported cache data came from.
+type SeedSource struct {
+	Context string `json:"context,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Region  string `json:"region,omitempty"`
+}
+
+// Seed is the versioned envelope written by `pcli cache export` and read by
+// `pcli cache import`, e.g. as a team-shared seed file.
+type Seed struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Source        SeedSource             `json:"source"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Entries       map[string]cacheRecord `json:"entries"`
+}
+
+// ImportResult reports what an import changed.
+type ImportResult struct {
+	Imported []string
+	Skipped  []string
+	// Unknown lists imported keys no registered provider produces
+	Unknown []string
+	// Incompatible lists keys whose data has a schema this pcli cannot
+	// read or upgrade; they are not imported
+	Incompatible []string
+}
+
+// ExportCache wraps all cache entries of target in a seed envelope.
+func ExportCache(target Target) (Seed, error) {
+	data, err := loadCache()
+	if err != nil {
+		return Seed{}, err
+	}
+
+	entries := data[target.Scope()]
+	if entries == nil {
+		entries = map[string]cacheRecord{}
+	}
+	return Seed{
+		SchemaVersion: SeedSchemaVersion,
+		Source:        SeedSource{Context: target.Context, Profile: target.Profile, Region: target.Region},
+		ExportedAt:    time.Now().UTC(),
+		Entries:       entries,
+	}, nil
+}
+
+// ReadSeed reads and validates a seed file; "-" reads from stdin.
+func ReadSeed(path string) (Seed, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return Seed{}, fmt.Errorf("read seed: %w", err)
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(raw, &seed); err != nil {
+		return Seed{}, fmt.Errorf("invalid seed file: %w", err)
+	}
+	if err := seed.Validate(); err != nil {
+		return Seed{}, err
+	}
+	return seed, nil
+}
+
+// Validate checks the envelope and every entry of a seed.
+func (s Seed) Validate() error {
+	switch {
+	case s.SchemaVersion == 0:
+		return fmt.Errorf("invalid seed file: missing schema_version")
+	case s.SchemaVersion > SeedSchemaVersion:
+		return fmt.Errorf("seed schema version %d is newer than supported version %d; upgrade pcli", s.SchemaVersion, SeedSchemaVersion)
+	case len(s.Entries) == 0:
+		return fmt.Errorf("invalid seed file: no entries")
+	}
+
+	for key, record := range s.Entries {
+		if record.Value == nil {
+			return fmt.Errorf("invalid seed entry '%s': missing value", key)
+		}
+		if record.TTL != "" {
+			if _, err := time.ParseDuration(record.TTL); err != nil {
+				return fmt.Errorf("invalid seed entry '%s': bad ttl %q", key, record.TTL)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportCache stores the entries of seed in the cache namespace of target.
+// Without merge the namespace is replaced entirely; with merge an entry is
+// only imported when it is missing or newer than the cached one. Entries of
+// older schemas are upgraded on the way in.
+func ImportCache(target Target, seed Seed, merge bool) (ImportResult, error) {
+	var result ImportResult
+	for key := range seed.Entries {
+		if _, ok := cacheProviders[key]; !ok {
+			result.Unknown = append(result.Unknown, key)
+		}
+	}
+
+	err := updateCache(func(data cacheData) {
+		scope := target.Scope()
+		if !merge || data[scope] == nil {
+			data[scope] = map[string]cacheRecord{}
+		}
+		for key, record := range seed.Entries {
+			record, err := migrateRecord(key, record)
+			if err != nil {
+				result.Incompatible = append(result.Incompatible, key)
+				continue
+			}
+			if existing, ok := data[scope][key]; ok && !record.FetchedAt.After(existing.FetchedAt) {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+			data[scope][key] = record
+			result.Imported = append(result.Imported, key)
+		}
+	})
+	return result, err
+}
+
+// seedImport records the automatic import of a seed file into one cache
+// namespace, so each version of the file is imported, or fails, only once.
+type seedImport struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	At      time.Time `json:"at"`
+	// Error is why the import failed, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// seedImportsPath returns the file recording automatic seed imports. It
+// lives next to the cache file, so clearing the cache does not bring the
+// seed back.
+func seedImportsPath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "seed_imports.json"), nil
+}
+
+// AutoImportSeed imports the seed file referenced by the cache_seed setting
+// into the cache namespace of target, so new team members start with a warm
+// cache. Each version of the file is imported once per namespace, whether
+// it succeeds or not, so a cleared cache stays empty and a broken seed is
+// reported only once. A relative path is resolved against the directory of
+// the config file that sets it, e.g. the project config. It returns the
+// path it imported, if any.
+func AutoImportSeed(target Target) (string, error) {
+	path := viper.GetString("cache_seed")
+	if path == "" {
+		return "", nil
+	}
+	if file := ConfigFileDefining("cache_seed"); !filepath.IsAbs(path) && file != "" {
+		path = filepath.Join(filepath.Dir(file), path)
+	}
+
+	importsPath, err := seedImportsPath()
+	if err != nil {
+		return "", err
+	}
+	unlock, err := lockFile(importsPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	imports := map[string]seedImport{}
+	if raw, err := os.ReadFile(importsPath); err == nil {
+		// A corrupt record only means the seed is imported again
+		json.Unmarshal(raw, &imports)
+	}
+
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().UTC()
+	}
+	scope := target.Scope()
+	if last, ok := imports[scope]; ok && last.Path == path && last.ModTime.Equal(modTime) {
+		return "", nil
+	}
+
+	seed, err := ReadSeed(path)
+	if err == nil {
+		_, err = ImportCache(target, seed, true)
+	}
+	record := seedImport{Path: path, ModTime: modTime, At: time.Now().UTC()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	imports[scope] = record
+
+	raw, marshalErr := json.MarshalIndent(imports, "", "  ")
+	if marshalErr == nil {
+		marshalErr = writeFileAtomic(importsPath, append(raw, '\n'), 0o600)
+	}
+	if err != nil {
+		return "", err
+	}
+	if marshalErr != nil {
+		return "", fmt.Errorf("record seed import: %w", marshalErr)
+	}
+	return path, nil
+}
@@ -0,0 +1,436 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Endpoints of IAM Identity Center; %s is the SSO region.
+var (
+	ssoOIDCEndpoint   = "https://oidc.%s.amazonaws.com"
+	ssoPortalEndpoint = "https://portal.sso.%s.amazonaws.com"
+)
+
+// ssoMu serialises token refreshes and role credential fetches so parallel
+// AWS calls share a single refresh.
+var ssoMu sync.Mutex
+
+var ssoHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// ErrSSOLoginRequired is returned when no usable SSO session exists.
+var ErrSSOLoginRequired = errors.New("SSO session missing or expired; run 'pcli auth login'")
+
+// SSOConfig identifies an IAM Identity Center instance and, optionally, the
+// account and role to fetch credentials for.
+type SSOConfig struct {
+	StartURL  string
+	Region    string
+	AccountID string
+	RoleName  string
+}
+
+// SSOToken is the cached result of the device authorization flow, together
+// with the client registration used to obtain and refresh it.
+type SSOToken struct {
+	StartURL              string    `json:"startUrl"`
+	Region                string    `json:"region"`
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	ClientID              string    `json:"clientId"`
+	ClientSecret          string    `json:"clientSecret"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt"`
+	// Refreshable is set for clients registered with the refresh_token
+	// grant; older registrations never received refresh tokens
+	Refreshable bool `json:"refreshable,omitempty"`
+}
+
+// Valid reports whether the access token can still be used.
+func (t SSOToken) Valid() bool {
+	return t.AccessToken != "" && time.Now().Add(credentialsExpiryMargin).Before(t.ExpiresAt)
+}
+
+// DeviceAuthorization is what the user needs to approve a login in the browser.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"deviceCode"`
+	UserCode                string `json:"userCode"`
+	VerificationURI         string `json:"verificationUri"`
+	VerificationURIComplete string `json:"verificationUriComplete"`
+	ExpiresIn               int    `json:"expiresIn"`
+	Interval                int    `json:"interval"`
+}
+
+// SSOConfigFor returns the SSO settings of target, falling back to the
+// sso.start_url/sso.region config defaults.
+func SSOConfigFor(target Target) SSOConfig {
+	cfg := SSOConfig{
+		StartURL:  target.SSOStartURL,
+		Region:    target.SSORegion,
+		AccountID: target.SSOAccountID,
+		RoleName:  target.SSORoleName,
+	}
+	if cfg.StartURL == "" {
+		cfg.StartURL = viper.GetString("sso.start_url")
+	}
+	if cfg.Region == "" {
+		cfg.Region = viper.GetString("sso.region")
+	}
+	return cfg
+}
+
+// UsesSSO reports whether target obtains its credentials via SSO.
+func (t Target) UsesSSO() bool {
+	return t.SSOAccountID != "" && t.SSORoleName != ""
+}
+
+// SSOLogin runs the device authorization flow: it registers a client, asks
+// show to present the verification URL and code to the user, and polls until
+// the login is approved. The resulting token is cached on disk.
+func SSOLogin(cfg SSOConfig, show func(DeviceAuthorization)) (SSOToken, error) {
+	if cfg.StartURL == "" || cfg.Region == "" {
+		return SSOToken{}, fmt.Errorf("SSO start URL and region are required (set sso.start_url and sso.region)")
+	}
+
+	ssoMu.Lock()
+	defer ssoMu.Unlock()
+
+	token, _ := loadSSOToken(cfg.StartURL)
+	token.StartURL, token.Region = cfg.StartURL, cfg.Region
+
+	// Client registrations are long-lived; only register again when needed
+	if token.ClientID == "" || !token.Refreshable || time.Now().After(token.RegistrationExpiresAt) {
+		var reg struct {
+			ClientID              string `json:"clientId"`
+			ClientSecret          string `json:"clientSecret"`
+			ClientSecretExpiresAt int64  `json:"clientSecretExpiresAt"`
+		}
+		err := ssoPost(cfg.Region, "/client/register", map[string]any{
+			"clientName": "pcli",
+			"clientType": "public",
+			"scopes":     []string{"sso:account:access"},
+			// Without refresh_token OIDC issues no refresh tokens, and every
+			// expired session would need a new login
+			"grantTypes": []string{"urn:ietf:params:oauth:grant-type:device_code", "refresh_token"},
+		}, &reg)
+		if err != nil {
+			return SSOToken{}, fmt.Errorf("register SSO client: %w", err)
+		}
+		token.ClientID = reg.ClientID
+		token.ClientSecret = reg.ClientSecret
+		token.RegistrationExpiresAt = time.Unix(reg.ClientSecretExpiresAt, 0)
+		token.Refreshable = true
+	}
+
+	var auth DeviceAuthorization
+	err := ssoPost(cfg.Region, "/device_authorization", map[string]any{
+		"clientId":     token.ClientID,
+		"clientSecret": token.ClientSecret,
+		"startUrl":     cfg.StartURL,
+	}, &auth)
+	if err != nil {
+		return SSOToken{}, fmt.Errorf("start device authorization: %w", err)
+	}
+	show(auth)
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		err := token.createToken(map[string]any{
+			"grantType":  "urn:ietf:params:oauth:grant-type:device_code",
+			"deviceCode": auth.DeviceCode,
+		})
+		var oauthErr *ssoError
+		switch {
+		case err == nil:
+			if err := saveSSOToken(token); err != nil {
+				return SSOToken{}, err
+			}
+			return token, nil
+		case errors.As(err, &oauthErr) && oauthErr.Code == "authorization_pending":
+			continue
+		case errors.As(err, &oauthErr) && oauthErr.Code == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return SSOToken{}, fmt.Errorf("create SSO token: %w", err)
+		}
+	}
+	return SSOToken{}, fmt.Errorf("device authorization expired before it was approved")
+}
+
+// SSOLogout signs out of the SSO session and removes the cached token and
+// role credentials of the start URL.
+func SSOLogout(cfg SSOConfig) error {
+	ssoMu.Lock()
+	defer ssoMu.Unlock()
+
+	token, err := loadSSOToken(cfg.StartURL)
+	if err != nil {
+		return fmt.Errorf("not logged in to %s", cfg.StartURL)
+	}
+
+	// Revoking is best effort; the local session is dropped either way
+	if token.Valid() {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(ssoPortalEndpoint, token.Region)+"/logout", nil)
+		if err == nil {
+			req.Header.Set("x-amz-sso_bearer_token", token.AccessToken)
+			if resp, err := ssoHTTPClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return err
+	}
+	prefix := cacheName(cfg.StartURL)
+	matches, _ := filepath.Glob(filepath.Join(dir, prefix+"*.json"))
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove SSO cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// SSOStatus returns the cached token of the start URL, if any.
+func SSOStatus(cfg SSOConfig) (SSOToken, error) {
+	return loadSSOToken(cfg.StartURL)
+}
+
+// SSOCredentials returns role credentials for the SSO account and role of
+// target. Cached credentials are reused until they expire; otherwise they are
+// fetched with the cached access token, which is refreshed first if needed.
+func SSOCredentials(target Target) (Credentials, error) {
+	cfg := SSOConfigFor(target)
+
+	ssoMu.Lock()
+	defer ssoMu.Unlock()
+
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return Credentials{}, err
+	}
+	credsPath := filepath.Join(dir, cacheName(cfg.StartURL)+"-"+cacheName(cfg.AccountID, cfg.RoleName)+".json")
+	if creds, err := loadCredentials(credsPath); err == nil && creds.Valid() {
+		return creds, nil
+	}
+
+	token, err := loadSSOToken(cfg.StartURL)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, ErrSSOLoginRequired
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("read SSO token: %w", err)
+	}
+	if !token.Valid() {
+		if token.RefreshToken == "" {
+			return Credentials{}, ErrSSOLoginRequired
+		}
+		err := token.createToken(map[string]any{
+			"grantType":    "refresh_token",
+			"refreshToken": token.RefreshToken,
+		})
+		if ssoSessionEnded(err) {
+			return Credentials{}, ErrSSOLoginRequired
+		}
+		if err != nil {
+			return Credentials{}, fmt.Errorf("refresh SSO token: %w", err)
+		}
+		if err := saveSSOToken(token); err != nil {
+			return Credentials{}, err
+		}
+	}
+
+	query := url.Values{"account_id": {cfg.AccountID}, "role_name": {cfg.RoleName}}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(ssoPortalEndpoint, token.Region)+"/federation/credentials?"+query.Encode(), nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("x-amz-sso_bearer_token", token.AccessToken)
+
+	var resp struct {
+		RoleCredentials struct {
+			AccessKeyID     string `json:"accessKeyId"`
+			SecretAccessKey string `json:"secretAccessKey"`
+			SessionToken    string `json:"sessionToken"`
+			Expiration      int64  `json:"expiration"`
+		} `json:"roleCredentials"`
+	}
+	if err := ssoDo(req, &resp); err != nil {
+		if ssoSessionEnded(err) {
+			return Credentials{}, ErrSSOLoginRequired
+		}
+		return Credentials{}, fmt.Errorf("get SSO role credentials: %w", err)
+	}
+
+	creds := Credentials{
+		AccessKeyID:     resp.RoleCredentials.AccessKeyID,
+		SecretAccessKey: resp.RoleCredentials.SecretAccessKey,
+		SessionToken:    resp.RoleCredentials.SessionToken,
+		Expiration:      time.UnixMilli(resp.RoleCredentials.Expiration),
+	}
+	if err := saveCredentials(credsPath, creds); err != nil {
+		return Credentials{}, err
+	}
+	return creds, nil
+}
+
+// createToken exchanges a grant for a new access token and stores it in t.
+func (t *SSOToken) createToken(grant map[string]any) error {
+	grant["clientId"] = t.ClientID
+	grant["clientSecret"] = t.ClientSecret
+
+	var resp struct {
+		AccessToken  string `json:"accessToken"`
+		ExpiresIn    int    `json:"expiresIn"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := ssoPost(t.Region, "/token", grant, &resp); err != nil {
+		return err
+	}
+
+	t.AccessToken = resp.AccessToken
+	t.ExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if resp.RefreshToken != "" {
+		t.RefreshToken = resp.RefreshToken
+	}
+	return nil
+}
+
+// ssoError is an error response of the SSO OIDC or portal API.
+type ssoError struct {
+	Status      int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *ssoError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	if e.Code != "" {
+		return e.Code
+	}
+	return fmt.Sprintf("HTTP %d", e.Status)
+}
+
+// ssoSessionEnded reports whether err means the SSO session is over and
+// only a new login helps: the API rejected the token or refresh token.
+// Network failures and server errors are not, logging in would fail too.
+func ssoSessionEnded(err error) bool {
+	var apiErr *ssoError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Status == http.StatusUnauthorized || apiErr.Code == "invalid_grant" || apiErr.Code == "expired_token"
+}
+
+// ssoPost sends a JSON request to the SSO OIDC API of region.
+func ssoPost(region, path string, body any, out any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(ssoOIDCEndpoint, region)+path, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return ssoDo(req, out)
+}
+
+// ssoDo executes req and decodes a successful JSON response into out.
+func ssoDo(req *http.Request, out any) error {
+	resp, err := ssoHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := &ssoError{Status: resp.StatusCode}
+		json.Unmarshal(raw, apiErr)
+		return apiErr
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// ssoCacheDir returns the directory SSO tokens and role credentials are
+// cached in.
+func ssoCacheDir() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sso"), nil
+}
+
+// cacheName hashes its parts into a short, file-name safe identifier.
+func cacheName(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// loadSSOToken reads the cached token of startURL.
+func loadSSOToken(startURL string) (SSOToken, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return SSOToken{}, err
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, cacheName(startURL)+".json"))
+	if err != nil {
+		return SSOToken{}, err
+	}
+	var token SSOToken
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return SSOToken{}, fmt.Errorf("unmarshal SSO token: %w", err)
+	}
+	return token, nil
+}
+
+// saveSSOToken caches token readable by the current user only.
+func saveSSOToken(token SSOToken) error {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create SSO cache: %w", err)
+	}
+	raw, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("marshal SSO token: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, cacheName(token.StartURL)+".json"), raw, 0o600); err != nil {
+		return fmt.Errorf("write SSO token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSSOServer points the SSO endpoints at a test server for the duration
+// of a test: /oidc/<region>/... and /portal/<region>/....
+func withSSOServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(handler)
+	oidc, portal := ssoOIDCEndpoint, ssoPortalEndpoint
+	t.Cleanup(func() {
+		server.Close()
+		ssoOIDCEndpoint, ssoPortalEndpoint = oidc, portal
+	})
+	ssoOIDCEndpoint = server.URL + "/oidc/%s"
+	ssoPortalEndpoint = server.URL + "/portal/%s"
+	return server
+}
+
+// writeJSON answers a test request with status and body.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// TestSSOLogin runs the device authorization flow against a fake OIDC API
+// that asks to keep polling once.
+func TestSSOLogin(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	polls := 0
+	withSSOServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/oidc/eu-west-1/client/register":
+			if grants, _ := json.Marshal(body["grantTypes"]); !strings.Contains(string(grants), "refresh_token") {
+				t.Errorf("client registered without the refresh_token grant: %s", grants)
+			}
+			writeJSON(w, 200, map[string]any{"clientId": "client", "clientSecret": "secret", "clientSecretExpiresAt": time.Now().Add(time.Hour).Unix()})
+		case "/oidc/eu-west-1/device_authorization":
+			writeJSON(w, 200, DeviceAuthorization{DeviceCode: "device", UserCode: "ABCD-EFGH", ExpiresIn: 60, Interval: 1})
+		case "/oidc/eu-west-1/token":
+			if polls++; polls == 1 {
+				writeJSON(w, 400, map[string]any{"error": "authorization_pending"})
+				return
+			}
+			if body["deviceCode"] != "device" || body["clientId"] != "client" {
+				t.Errorf("token request = %v", body)
+			}
+			writeJSON(w, 200, map[string]any{"accessToken": "access", "expiresIn": 3600, "refreshToken": "refresh"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	cfg := SSOConfig{StartURL: "https://example.awsapps.com/start", Region: "eu-west-1"}
+	var shown DeviceAuthorization
+	token, err := SSOLogin(cfg, func(auth DeviceAuthorization) { shown = auth })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shown.UserCode != "ABCD-EFGH" {
+		t.Errorf("shown = %+v", shown)
+	}
+	if polls != 2 {
+		t.Errorf("polled %d times, want 2", polls)
+	}
+	cached, err := SSOStatus(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.Valid() || cached.RefreshToken != "refresh" || !cached.Refreshable || cached.AccessToken != token.AccessToken {
+		t.Errorf("cached token = %+v", cached)
+	}
+}
+
+// TestSSOCredentials checks that expired sessions are refreshed, and that
+// only a missing or rejected session asks for a new login.
+func TestSSOCredentials(t *testing.T) {
+	const startURL = "https://example.awsapps.com/start"
+	expired := SSOToken{
+		StartURL: startURL, Region: "eu-west-1", AccessToken: "old", ExpiresAt: time.Now().Add(-time.Hour),
+		RefreshToken: "refresh", ClientID: "client", ClientSecret: "secret", Refreshable: true,
+	}
+	valid := expired
+	valid.AccessToken, valid.ExpiresAt = "current", time.Now().Add(time.Hour)
+
+	credentials := map[string]any{"roleCredentials": map[string]any{
+		"accessKeyId": "AKIA", "secretAccessKey": "secret", "sessionToken": "session",
+		"expiration": time.Now().Add(time.Hour).UnixMilli(),
+	}}
+	tests := []struct {
+		name        string
+		token       *SSOToken
+		raw         string
+		refresh     func(w http.ResponseWriter)
+		federation  int
+		wantErr     string
+		wantLogin   bool
+		wantRefresh bool
+	}{
+		{name: "not logged in", wantLogin: true},
+		{name: "corrupt token", raw: "{", wantErr: "read SSO token"},
+		{name: "valid token", token: &valid, federation: 200},
+		{
+			name:  "refreshed",
+			token: &expired,
+			refresh: func(w http.ResponseWriter) {
+				writeJSON(w, 200, map[string]any{"accessToken": "new", "expiresIn": 3600})
+			},
+			federation:  200,
+			wantRefresh: true,
+		},
+		{
+			name:      "refresh token expired",
+			token:     &expired,
+			refresh:   func(w http.ResponseWriter) { writeJSON(w, 400, map[string]any{"error": "invalid_grant"}) },
+			wantLogin: true,
+		},
+		{
+			name:    "refresh server error",
+			token:   &expired,
+			refresh: func(w http.ResponseWriter) { writeJSON(w, 503, map[string]any{}) },
+			wantErr: "refresh SSO token: HTTP 503",
+		},
+		{name: "role credentials rejected", token: &valid, federation: 401, wantLogin: true},
+		{name: "role credentials server error", token: &valid, federation: 500, wantErr: "get SSO role credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			withSSOServer(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/oidc/eu-west-1/token":
+					if tt.refresh == nil {
+						t.Error("unexpected token refresh")
+					}
+					tt.refresh(w)
+				case "/portal/eu-west-1/federation/credentials":
+					want := valid.AccessToken
+					if tt.wantRefresh {
+						want = "new"
+					}
+					if got := r.Header.Get("x-amz-sso_bearer_token"); got != want {
+						t.Errorf("bearer token = %q, want %q", got, want)
+					}
+					writeJSON(w, tt.federation, credentials)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			if tt.token != nil {
+				if err := saveSSOToken(*tt.token); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.raw != "" {
+				dir, _ := ssoCacheDir()
+				os.MkdirAll(dir, 0o700)
+				if err := os.WriteFile(filepath.Join(dir, cacheName(startURL)+".json"), []byte(tt.raw), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			target := Target{SSOStartURL: startURL, SSORegion: "eu-west-1", SSOAccountID: "111111111111", SSORoleName: "ReadOnly"}
+			creds, err := SSOCredentials(target)
+			switch {
+			case tt.wantLogin:
+				if !errors.Is(err, ErrSSOLoginRequired) {
+					t.Errorf("error = %v, want a new login", err)
+				}
+				return
+			case tt.wantErr != "":
+				if err == nil || errors.Is(err, ErrSSOLoginRequired) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+			if creds.AccessKeyID != "AKIA" || !creds.Valid() {
+				t.Errorf("credentials = %+v", creds)
+			}
+			if tt.wantRefresh {
+				if token, _ := loadSSOToken(startURL); token.AccessToken != "new" || token.RefreshToken != "refresh" {
+					t.Errorf("refreshed token not saved: %+v", token)
+				}
+			}
+		})
+	}
+}
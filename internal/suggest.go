@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// Suggest returns the candidates closest to input, best match first, for
+// "did you mean" hints. Candidates are compared case-insensitively and with
+// '-' and '_' treated alike; only reasonably close matches are returned.
+func Suggest(input string, candidates []string) []string {
+	normalize := func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(s), "-", "_")
+	}
+	in := normalize(input)
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	var matches []scored
+	for _, candidate := range candidates {
+		c := normalize(candidate)
+		d := levenshtein(in, c)
+		// Allow roughly one typo per three characters, or a prefix/substring
+		if d <= max(1, len(c)/3) || (len(in) >= 3 && strings.Contains(c, in)) {
+			matches = append(matches, scored{candidate, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.name
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with -ldflags, e.g.
+//
+//	-X github.com/rashi1281/pcli/internal.Version=v1.2.3
+//	-X github.com/rashi1281/pcli/internal.Commit=$(git rev-parse HEAD)
+//	-X github.com/rashi1281/pcli/internal.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+//
+// Commit and BuildDate default to the VCS data the go command embeds.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty" yaml:"build_date,omitempty"`
+	// Modified is set when the binary was built from a dirty work tree
+	Modified  bool   `json:"modified,omitempty" yaml:"modified,omitempty"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	Platform  string `json:"platform" yaml:"platform"`
+	// ConfigVersion is the config file version this binary writes
+	ConfigVersion string        `json:"config_version" yaml:"config_version"`
+	Deps          []BuildModule `json:"deps,omitempty" yaml:"deps,omitempty"`
+}
+
+// BuildModule is a module compiled into the binary.
+type BuildModule struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version" yaml:"version"`
+	// Replace is the module path or directory it was replaced by
+	Replace string `json:"replace,omitempty" yaml:"replace,omitempty"`
+}
+
+// BuildVersion returns the version of the running binary: Version when set
+// at build time, otherwise the module version recorded by go install, and
+// "dev" for local builds. It never comes from the environment.
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// ReadBuildInfo returns the build metadata of the running binary, from the
+// -ldflags variables and what the go command recorded in it.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:       BuildVersion(),
+		Commit:        Commit,
+		BuildDate:     BuildDate,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		ConfigVersion: ConfigVersion,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = build.GoVersion
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	for _, dep := range build.Deps {
+		module := BuildModule{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			module.Version = dep.Replace.Version
+			module.Replace = dep.Replace.Path
+		}
+		info.Deps = append(info.Deps, module)
+	}
+	return info
+}
+
+// ShortCommit returns the first 12 characters of the commit, enough to
+// identify it.
+func (b BuildInfo) ShortCommit() string {
+	if len(b.Commit) > 12 {
+		return b.Commit[:12]
+	}
+	return b.Commit
+}
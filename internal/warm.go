@@ -0,0 +1,390 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultWarmInterval is how often the cache warmer looks for stale entries
+// at the latest, even when no entry expires sooner.
+const DefaultWarmInterval = 5 * time.Minute
+
+// minWarmWait keeps the warmer from spinning when entries expire back to
+// back.
+const minWarmWait = 30 * time.Second
+
+// maxWarmLogSize is the size beyond which the warm log is rotated on start.
+const maxWarmLogSize = 1 << 20
+
+// WarmUnitName is the systemd user unit installed by InstallWarmUnit.
+const WarmUnitName = "pcli-cache-warm.service"
+
+// ErrWarmRunning is returned when another cache warmer holds the lock.
+var ErrWarmRunning = errors.New("cache warmer is already running")
+
+// WarmPIDPath returns the file holding the PID of the running cache warmer.
+// The warmer keeps the lock of this file for as long as it runs.
+func WarmPIDPath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "warm.pid"), nil
+}
+
+// WarmLogPath returns the file the cache warmer logs refresh outcomes to.
+func WarmLogPath() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "warm.log"), nil
+}
+
+// WarmTargets returns the targets the cache warmer keeps fresh: the contexts
+// listed under cache_warm.contexts, or the current target.
+func WarmTargets() ([]Target, error) {
+	names := viper.GetStringSlice("cache_warm.contexts")
+	if len(names) == 0 {
+		target, err := CurrentTarget()
+		if err != nil {
+			return nil, err
+		}
+		return []Target{target}, nil
+	}
+
+	var targets []Target
+	for _, name := range names {
+		target, err := ResolveTarget(name)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// WarmCache refreshes the providers of every warm target whenever their
+// entries go stale, until ctx is cancelled. Outcomes are logged to the warm
+// log and to out, if given. Only one warmer runs at a time; a second one
+// returns ErrWarmRunning.
+func WarmCache(ctx context.Context, interval time.Duration, out io.Writer) error {
+	if interval <= 0 {
+		interval = DefaultWarmInterval
+	}
+
+	pidPath, err := WarmPIDPath()
+	if err != nil {
+		return err
+	}
+	release, ok, err := tryLockFile(pidPath)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWarmRunning
+	}
+	defer release()
+
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write pid file: %w", err)
+	}
+	defer os.Remove(pidPath)
+
+	logFile, err := openWarmLog()
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	var w io.Writer = logFile
+	if out != nil {
+		w = io.MultiWriter(logFile, out)
+	}
+	logger := log.New(w, "", log.LstdFlags)
+
+	logger.Printf("started (pid %d, checking at least every %s)", os.Getpid(), interval)
+	defer logger.Printf("stopped")
+
+	for {
+		wait := interval
+		targets, err := WarmTargets()
+		if err != nil {
+			logger.Printf("error: %v", err)
+		}
+		for _, target := range targets {
+			if next := warmTarget(ctx, target, logger); next < wait {
+				wait = next
+			}
+		}
+		if wait < minWarmWait {
+			wait = minWarmWait
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// warmTarget refreshes the providers of target whose entries are missing or
+// stale and returns how long until the next entry goes stale.
+func warmTarget(ctx context.Context, target Target, logger *log.Logger) time.Duration {
+	// Nobody is around to answer an MFA prompt; cached credentials are used
+	// until they expire
+	target.NoPrompt = true
+
+	var due []string
+	for _, p := range CacheProviders() {
+		if entry, ok := GetCacheEntry(target, p.Name); !ok || entry.Stale() {
+			due = append(due, p.Name)
+		}
+	}
+
+	if len(due) > 0 {
+		providers, err := ResolveCacheProviders(due)
+		if err != nil {
+			logger.Printf("%s: %v", target, err)
+			return math.MaxInt64
+		}
+		results := RefreshCacheProviders(ctx, target, providers, RefreshOptionsFromConfig(), nil)
+		for _, r := range results {
+			switch {
+			case r.Err != nil:
+				logger.Printf("%s: %s failed: %v", target, r.Provider, r.Err)
+			case r.Items >= 0:
+				logger.Printf("%s: %s refreshed in %s (%d items)", target, r.Provider, r.Duration.Round(time.Millisecond), r.Items)
+			default:
+				logger.Printf("%s: %s refreshed in %s", target, r.Provider, r.Duration.Round(time.Millisecond))
+			}
+		}
+	}
+
+	// Providers that failed stay stale and are retried on the next check
+	// interval rather than hammered
+	next := time.Duration(math.MaxInt64)
+	for _, p := range CacheProviders() {
+		entry, ok := GetCacheEntry(target, p.Name)
+		if !ok || entry.Stale() {
+			continue
+		}
+		if until := time.Until(entry.ExpiresAt()); until < next {
+			next = until
+		}
+	}
+	return next
+}
+
+// openWarmLog opens the warm log for appending, rotating it to warm.log.1
+// once it has grown too large.
+func openWarmLog() (*os.File, error) {
+	path, err := WarmLogPath()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxWarmLogSize {
+		os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open warm log: %w", err)
+	}
+	return f, nil
+}
+
+// WarmStatus describes the cache warmer process, if one is running.
+type WarmStatus struct {
+	Running bool
+	PID     int
+	Since   time.Time
+}
+
+// GetWarmStatus reports whether a cache warmer is running.
+func GetWarmStatus() (WarmStatus, error) {
+	pidPath, err := WarmPIDPath()
+	if err != nil {
+		return WarmStatus{}, err
+	}
+	release, ok, err := tryLockFile(pidPath)
+	if err != nil {
+		return WarmStatus{}, err
+	}
+	if ok {
+		release()
+		return WarmStatus{}, nil
+	}
+
+	status := WarmStatus{Running: true}
+	if info, err := os.Stat(pidPath); err == nil {
+		status.Since = info.ModTime()
+	}
+	if data, err := os.ReadFile(pidPath); err == nil {
+		status.PID, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	return status, nil
+}
+
+// StopWarm stops the running cache warmer and waits for it to exit. It
+// returns the PID of the stopped process.
+func StopWarm() (int, error) {
+	status, err := GetWarmStatus()
+	if err != nil {
+		return 0, err
+	}
+	if !status.Running {
+		return 0, errors.New("cache warmer is not running")
+	}
+	if status.PID == 0 {
+		return 0, errors.New("cache warmer is running but its PID is unknown")
+	}
+
+	proc, err := os.FindProcess(status.PID)
+	if err != nil {
+		return 0, err
+	}
+	if err := terminateProcess(proc); err != nil {
+		return 0, fmt.Errorf("stop process %d: %w", status.PID, err)
+	}
+
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if current, err := GetWarmStatus(); err == nil && !current.Running {
+			return status.PID, nil
+		}
+	}
+	return 0, fmt.Errorf("process %d did not stop", status.PID)
+}
+
+// StartWarmDaemon starts `pcli cache warm` as a detached background process
+// for the current config and target and returns its PID.
+func StartWarmDaemon(interval time.Duration) (int, error) {
+	status, err := GetWarmStatus()
+	if err != nil {
+		return 0, err
+	}
+	if status.Running {
+		return status.PID, ErrWarmRunning
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	// The daemon logs to the warm log itself, so its stdio can be discarded
+	warm := exec.Command(exe, warmArgs(interval)...)
+	warm.SysProcAttr = detachedProcAttr()
+	if err := warm.Start(); err != nil {
+		return 0, err
+	}
+	pid := warm.Process.Pid
+	return pid, warm.Process.Release()
+}
+
+// warmArgs returns the arguments that run the cache warmer in the foreground.
+func warmArgs(interval time.Duration) []string {
+	args := []string{"cache", "warm", "--quiet"}
+	if interval > 0 {
+		args = append(args, "--interval", interval.String())
+	}
+	return append(args, overrideArgs()...)
+}
+
+// InstallWarmUnit writes a systemd user unit that runs the cache warmer for
+// the current config and target, and returns its path.
+func InstallWarmUnit(interval time.Duration) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	dir = filepath.Join(dir, "systemd", "user")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var execStart strings.Builder
+	execStart.WriteString(systemdQuote(exe))
+	for _, arg := range warmArgs(interval) {
+		execStart.WriteString(" " + systemdQuote(arg))
+	}
+
+	unit := fmt.Sprintf(`[Unit]
+Description=pcli cache warmer
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`, execStart.String())
+
+	path := filepath.Join(dir, WarmUnitName)
+	if err := writeFileAtomic(path, []byte(unit), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// systemdQuote quotes s for an ExecStart line when it contains characters
+// systemd would otherwise split or expand.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$%;") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// TailWarmLog returns the last n lines of the warm log.
+func TailWarmLog(n int) ([]string, error) {
+	path, err := WarmLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}